@@ -0,0 +1,123 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command managed-certificate-controller runs the ManagedCertificate reconcile loops.
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/golang/glog"
+	"k8s.io/client-go/rest"
+
+	"managed-certs-gke/pkg/config"
+
+	"github.com/GoogleCloudPlatform/gke-managed-certs/pkg/client"
+	"github.com/GoogleCloudPlatform/gke-managed-certs/pkg/controller"
+	"github.com/GoogleCloudPlatform/gke-managed-certs/pkg/controller/sslcertificatemanager"
+	"github.com/GoogleCloudPlatform/gke-managed-certs/pkg/issuer"
+)
+
+// configPath points at the ControllerConfiguration YAML file described in
+// pkg/apis/config/v1alpha1; an empty value runs with every field defaulted.
+var configPath = flag.String("config", "", "Path to a config.gke.io/v1alpha1 ControllerConfiguration file")
+
+func main() {
+	flag.Parse()
+	defer glog.Flush()
+
+	cfg, err := config.LoadConfiguration(*configPath)
+	if err != nil {
+		glog.Fatalf("Could not load controller configuration: %v", err)
+	}
+
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		glog.Fatalf("Could not build in-cluster client config: %v", err)
+	}
+
+	clients, err := client.New(restConfig, cfg.GCEProject, cfg.GCERegion)
+	if err != nil {
+		glog.Fatalf("Could not initialize clients: %v", err)
+	}
+
+	sslManager := sslcertificatemanager.New(clients, *cfg.EventVerbosity, cfg.ExpiryWarningThresholds)
+
+	var acmeIssuer issuer.Issuer
+	if cfg.ACME.DirectoryURL != "" {
+		accountKey, err := loadACMEAccountKey(cfg.ACME.AccountKeyFile)
+		if err != nil {
+			glog.Fatalf("Could not load ACME account key: %v", err)
+		}
+
+		acmeIssuer, err = issuer.NewACMEIssuer(cfg.ACME.DirectoryURL, accountKey, clients.Kubernetes)
+		if err != nil {
+			glog.Fatalf("Could not initialize ACME issuer: %v", err)
+		}
+	}
+
+	opts, err := config.NewControllerOptions(restConfig, clients, sslManager, acmeIssuer, cfg)
+	if err != nil {
+		glog.Fatalf("Could not build controller options: %v", err)
+	}
+
+	stopChannel := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-signals
+		glog.Info("Received termination signal, shutting down")
+		close(stopChannel)
+	}()
+
+	if err := controller.NewController(opts).Run(stopChannel); err != nil {
+		glog.Fatalf("Controller exited with error: %v", err)
+	}
+}
+
+// loadACMEAccountKey reads and parses the PEM-encoded EC private key at path. An empty path
+// returns a nil key, which issuer.NewACMEIssuer treats as "generate one", since an ACME account
+// doesn't require a key persisted across restarts to work, only to keep the same account.
+func loadACMEAccountKey(path string) (*ecdsa.PrivateKey, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %v", path, err)
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("%s does not contain a PEM-encoded key", path)
+	}
+
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse EC private key in %s: %v", path, err)
+	}
+
+	return key, nil
+}
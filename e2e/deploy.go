@@ -26,6 +26,8 @@ import (
 	apiextv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog"
+	configv1alpha1 "managed-certs-gke/pkg/apis/config/v1alpha1"
+	"sigs.k8s.io/yaml"
 
 	"github.com/GoogleCloudPlatform/gke-managed-certs/e2e/utils"
 	utilshttp "github.com/GoogleCloudPlatform/gke-managed-certs/pkg/utils/http"
@@ -36,6 +38,9 @@ const (
 	clusterRoleName        = "managed-certificate-role"
 	deploymentName         = "managed-certificate-controller"
 	serviceAccountName     = "managed-certificate-account"
+	configMapName          = "managed-certificate-config"
+	configMountPath        = "/etc/managed-certificate-controller"
+	configFileName         = "config.yaml"
 )
 
 // Deploys Managed Certificate CRD
@@ -141,6 +146,14 @@ func deployCRD() error {
 												},
 											},
 										},
+										"issuerRef": {
+											Type: "object",
+											Properties: map[string]apiextv1beta1.JSONSchemaProps{
+												"name":  {Type: "string"},
+												"kind":  {Type: "string"},
+												"group": {Type: "string"},
+											},
+										},
 									},
 								},
 							},
@@ -210,6 +223,11 @@ func deployController(tag string) error {
 				Resources: []string{"configmaps", "endpoints", "events", "ingresses"},
 				Verbs:     []string{"*"},
 			},
+			{
+				APIGroups: []string{"coordination.k8s.io"},
+				Resources: []string{"leases"},
+				Verbs:     []string{"*"},
+			},
 		},
 	}
 	if _, err := clients.ClusterRole.Create(&clusterRole); err != nil {
@@ -231,6 +249,30 @@ func deployController(tag string) error {
 	}
 	klog.Infof("Created cluster role binding %s", clusterRoleBindingName)
 
+	// Leader election must be enabled whenever more than one replica is deployed, or every
+	// replica races the others on GCE Create/Delete calls. ResourceNamespace matches where the
+	// deployment itself runs, "default", so the Lease the replicas contend for lives alongside
+	// the controller that owns it.
+	controllerConfig := configv1alpha1.ControllerConfiguration{
+		LeaderElection: configv1alpha1.LeaderElectionConfiguration{
+			Enabled:           true,
+			ResourceNamespace: "default",
+		},
+	}
+	configYAML, err := yaml.Marshal(&controllerConfig)
+	if err != nil {
+		return err
+	}
+
+	configMap := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: configMapName},
+		Data:       map[string]string{configFileName: string(configYAML)},
+	}
+	if _, err := clients.ConfigMap.Create(&configMap); err != nil {
+		return err
+	}
+	klog.Infof("Created config map %s", configMapName)
+
 	appCtrl := map[string]string{"app": deploymentName}
 	image := fmt.Sprintf("eu.gcr.io/managed-certs-gke/managed-certificate-controller:%s", tag)
 	fileOrCreate := corev1.HostPathFileOrCreate
@@ -244,9 +286,16 @@ func deployController(tag string) error {
 	logFileVolume := "logfile"
 	logFileVolumePath := "/var/log/managed_certificate_controller.log"
 
+	configVolume := "config"
+
+	var replicas int32 = 2
+
 	deployment := appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{Name: deploymentName},
 		Spec: appsv1.DeploymentSpec{
+			// Run 2 replicas so the e2e suite exercises leader election: the configMap mounted
+			// below enables it, so only one replica is ever actively reconciling at a time.
+			Replicas: &replicas,
 			Selector: &metav1.LabelSelector{MatchLabels: appCtrl},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{Labels: appCtrl},
@@ -258,6 +307,7 @@ func deployController(tag string) error {
 							Name:            deploymentName,
 							Image:           image,
 							ImagePullPolicy: corev1.PullAlways,
+							Args:            []string{fmt.Sprintf("--config=%s/%s", configMountPath, configFileName)},
 							VolumeMounts: []corev1.VolumeMount{
 								{
 									Name:      sslCertsVolume,
@@ -274,6 +324,11 @@ func deployController(tag string) error {
 									MountPath: logFileVolumePath,
 									ReadOnly:  false,
 								},
+								{
+									Name:      configVolume,
+									MountPath: configMountPath,
+									ReadOnly:  true,
+								},
 							},
 						},
 					},
@@ -303,6 +358,14 @@ func deployController(tag string) error {
 								},
 							},
 						},
+						{
+							Name: configVolume,
+							VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{Name: configMapName},
+								},
+							},
+						},
 					},
 				},
 			},
@@ -338,5 +401,10 @@ func deleteController() error {
 	}
 	klog.Infof("Deleted deployment %s", deploymentName)
 
+	if err := utilshttp.IgnoreNotFound(clients.ConfigMap.Delete(configMapName, &metav1.DeleteOptions{})); err != nil {
+		return err
+	}
+	klog.Infof("Deleted config map %s", configMapName)
+
 	return nil
 }
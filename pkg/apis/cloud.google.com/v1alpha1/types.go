@@ -0,0 +1,107 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package v1alpha1 is the v1alpha1 version of the cloud.google.com ManagedCertificate CRD, the
+// custom resource this controller reconciles into a certificate object - a GCE SslCertificate by
+// default, or an external Issuer's certificate when spec.issuerRef selects one.
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/GoogleCloudPlatform/gke-managed-certs/pkg/apis/networking.gke.io/v1beta2"
+)
+
+// ManagedCertificate is the user-facing custom resource requesting a TLS certificate for one or
+// more domains, provisioned and kept up to date by this controller.
+type ManagedCertificate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +optional
+	Spec ManagedCertificateSpec `json:"spec,omitempty"`
+	// +optional
+	Status ManagedCertificateStatus `json:"status,omitempty"`
+}
+
+// ManagedCertificateSpec is the desired state of a ManagedCertificate.
+type ManagedCertificateSpec struct {
+	// Domains this certificate should cover.
+	Domains []string `json:"domains"`
+
+	// IssuerRef selects the Issuer that should back this ManagedCertificate, e.g. an external ACME
+	// issuer instead of the default GCE SslCertificate issuer. A ManagedCertificate that predates
+	// this field, or that leaves it unset, falls back to the deprecated networking.gke.io/issuer-*
+	// annotations, via v1beta2.IssuerRefFromAnnotations.
+	// +optional
+	IssuerRef *v1beta2.IssuerReference `json:"issuerRef,omitempty"`
+}
+
+// ManagedCertificateStatus is the observed state of a ManagedCertificate.
+type ManagedCertificateStatus struct {
+	// CertificateName is the name of the issuer-specific certificate object currently serving this
+	// ManagedCertificate - an SslCertificate name for the GCE issuer, or a TLS Secret name for the
+	// ACME issuer.
+	// +optional
+	CertificateName string `json:"certificateName,omitempty"`
+}
+
+// ManagedCertificateList is a list of ManagedCertificates.
+type ManagedCertificateList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ManagedCertificate `json:"items"`
+}
+
+// DeepCopyObject implements runtime.Object. It is hand-written rather than generated by
+// deepcopy-gen, since that generator isn't wired up for this API group yet, mirroring the same
+// convention used for config.gke.io/v1alpha1.ControllerConfiguration.
+func (m *ManagedCertificate) DeepCopyObject() runtime.Object {
+	if m == nil {
+		return nil
+	}
+	out := new(ManagedCertificate)
+	out.TypeMeta = m.TypeMeta
+	out.ObjectMeta = *m.ObjectMeta.DeepCopy()
+	out.Status = m.Status
+	out.Spec.Domains = append([]string(nil), m.Spec.Domains...)
+	if m.Spec.IssuerRef != nil {
+		issuerRef := *m.Spec.IssuerRef
+		out.Spec.IssuerRef = &issuerRef
+	}
+	return out
+}
+
+// DeepCopyObject implements runtime.Object, hand-written for the same reason as
+// ManagedCertificate.DeepCopyObject.
+func (l *ManagedCertificateList) DeepCopyObject() runtime.Object {
+	if l == nil {
+		return nil
+	}
+	out := new(ManagedCertificateList)
+	out.TypeMeta = l.TypeMeta
+	out.ListMeta = l.ListMeta
+	if l.Items != nil {
+		out.Items = make([]ManagedCertificate, len(l.Items))
+		for i := range l.Items {
+			out.Items[i] = *l.Items[i].DeepCopyObject().(*ManagedCertificate)
+		}
+	}
+	return out
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Defaults preserve today's hardcoded behavior for every field an operator doesn't set.
+const (
+	DefaultWorkerResyncPeriod        = time.Second
+	DefaultSslCertificateSweepPeriod = time.Hour
+	DefaultRotationWindow            = 30 * 24 * time.Hour
+
+	// DefaultProvisioningRequeuePeriod is how soon a ManagedCertificate is checked again while its
+	// certificate, or a rotation replacement, has not yet reached ACTIVE.
+	DefaultProvisioningRequeuePeriod = 30 * time.Second
+
+	// DefaultActiveRequeuePeriod is the long backoff used once a certificate is ACTIVE and not yet
+	// close to its rotation window, so a large cluster isn't polled against the Issuer's backend
+	// every minute.
+	DefaultActiveRequeuePeriod = time.Hour
+
+	// DefaultRequeueJitter is added to DefaultActiveRequeuePeriod so that certificates created
+	// around the same time don't all come up for rotation, and backend polling, in the same
+	// instant.
+	DefaultRequeueJitter = 5 * time.Minute
+
+	DefaultWorkqueueBaseDelay = 5 * time.Millisecond
+	DefaultWorkqueueMaxDelay  = 1000 * time.Second
+	DefaultWorkqueueQPS       = 10
+	DefaultWorkqueueBurst     = 100
+
+	// DefaultEventVerbosity preserves today's behavior of always recording the informational
+	// Create/Delete events alongside the always-on Warning events.
+	DefaultEventVerbosity = 1
+
+	DefaultLeaderElectionResourceName      = "managed-certificate-controller"
+	DefaultLeaderElectionResourceNamespace = "kube-system"
+	DefaultLeaseDuration                   = 15 * time.Second
+	DefaultRenewDeadline                   = 10 * time.Second
+	DefaultRetryPeriod                     = 2 * time.Second
+)
+
+// DefaultExpiryWarningThresholds preserves today's hardcoded {7, 14, 30} warning days. It is a var,
+// not a const, because Go has no const slices; callers must not mutate the returned slice in place.
+var DefaultExpiryWarningThresholds = []int32{7, 14, 30}
+
+// SetDefaults_ControllerConfiguration fills in zero-valued fields of c with their defaults. It
+// follows the k8s convention of only touching fields the caller left unset, so that a partially
+// specified config file layers on top of these defaults rather than replacing them wholesale.
+func SetDefaults_ControllerConfiguration(c *ControllerConfiguration) {
+	if c.WorkerResyncPeriod.Duration == 0 {
+		c.WorkerResyncPeriod = metav1.Duration{Duration: DefaultWorkerResyncPeriod}
+	}
+	if c.SslCertificateSweepPeriod.Duration == 0 {
+		c.SslCertificateSweepPeriod = metav1.Duration{Duration: DefaultSslCertificateSweepPeriod}
+	}
+
+	if c.WorkqueueRateLimiter.BaseDelay.Duration == 0 {
+		c.WorkqueueRateLimiter.BaseDelay = metav1.Duration{Duration: DefaultWorkqueueBaseDelay}
+	}
+	if c.WorkqueueRateLimiter.MaxDelay.Duration == 0 {
+		c.WorkqueueRateLimiter.MaxDelay = metav1.Duration{Duration: DefaultWorkqueueMaxDelay}
+	}
+	if c.WorkqueueRateLimiter.QPS == 0 {
+		c.WorkqueueRateLimiter.QPS = DefaultWorkqueueQPS
+	}
+	if c.WorkqueueRateLimiter.Burst == 0 {
+		c.WorkqueueRateLimiter.Burst = DefaultWorkqueueBurst
+	}
+
+	if c.EventVerbosity == nil {
+		eventVerbosity := int32(DefaultEventVerbosity)
+		c.EventVerbosity = &eventVerbosity
+	}
+
+	if c.ExpiryWarningThresholds == nil {
+		c.ExpiryWarningThresholds = append([]int32(nil), DefaultExpiryWarningThresholds...)
+	}
+
+	if c.Rotation.Window.Duration == 0 {
+		c.Rotation.Window = metav1.Duration{Duration: DefaultRotationWindow}
+	}
+	if c.Rotation.ProvisioningRequeuePeriod.Duration == 0 {
+		c.Rotation.ProvisioningRequeuePeriod = metav1.Duration{Duration: DefaultProvisioningRequeuePeriod}
+	}
+	if c.Rotation.ActiveRequeuePeriod.Duration == 0 {
+		c.Rotation.ActiveRequeuePeriod = metav1.Duration{Duration: DefaultActiveRequeuePeriod}
+	}
+	if c.Rotation.RequeueJitter.Duration == 0 {
+		c.Rotation.RequeueJitter = metav1.Duration{Duration: DefaultRequeueJitter}
+	}
+
+	if c.LeaderElection.ResourceName == "" {
+		c.LeaderElection.ResourceName = DefaultLeaderElectionResourceName
+	}
+	if c.LeaderElection.ResourceNamespace == "" {
+		c.LeaderElection.ResourceNamespace = DefaultLeaderElectionResourceNamespace
+	}
+	if c.LeaderElection.LeaseDuration.Duration == 0 {
+		c.LeaderElection.LeaseDuration = metav1.Duration{Duration: DefaultLeaseDuration}
+	}
+	if c.LeaderElection.RenewDeadline.Duration == 0 {
+		c.LeaderElection.RenewDeadline = metav1.Duration{Duration: DefaultRenewDeadline}
+	}
+	if c.LeaderElection.RetryPeriod.Duration == 0 {
+		c.LeaderElection.RetryPeriod = metav1.Duration{Duration: DefaultRetryPeriod}
+	}
+}
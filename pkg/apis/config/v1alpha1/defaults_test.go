@@ -0,0 +1,91 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestSetDefaultsControllerConfigurationZeroValue checks that every field of a zero-valued
+// ControllerConfiguration - the result of parsing an empty or missing --config file - is filled in
+// with its documented default, preserving the hardcoded behavior that predates the --config flag.
+func TestSetDefaultsControllerConfigurationZeroValue(t *testing.T) {
+	c := &ControllerConfiguration{}
+	SetDefaults_ControllerConfiguration(c)
+
+	eventVerbosity := int32(DefaultEventVerbosity)
+	want := ControllerConfiguration{
+		WorkerResyncPeriod:        metav1.Duration{Duration: DefaultWorkerResyncPeriod},
+		SslCertificateSweepPeriod: metav1.Duration{Duration: DefaultSslCertificateSweepPeriod},
+		WorkqueueRateLimiter: WorkqueueRateLimiterConfiguration{
+			BaseDelay: metav1.Duration{Duration: DefaultWorkqueueBaseDelay},
+			MaxDelay:  metav1.Duration{Duration: DefaultWorkqueueMaxDelay},
+			QPS:       DefaultWorkqueueQPS,
+			Burst:     DefaultWorkqueueBurst,
+		},
+		EventVerbosity:          &eventVerbosity,
+		ExpiryWarningThresholds: append([]int32(nil), DefaultExpiryWarningThresholds...),
+		Rotation: RotationConfiguration{
+			Window:                    metav1.Duration{Duration: DefaultRotationWindow},
+			ProvisioningRequeuePeriod: metav1.Duration{Duration: DefaultProvisioningRequeuePeriod},
+			ActiveRequeuePeriod:       metav1.Duration{Duration: DefaultActiveRequeuePeriod},
+			RequeueJitter:             metav1.Duration{Duration: DefaultRequeueJitter},
+		},
+		LeaderElection: LeaderElectionConfiguration{
+			ResourceName:      DefaultLeaderElectionResourceName,
+			ResourceNamespace: DefaultLeaderElectionResourceNamespace,
+			LeaseDuration:     metav1.Duration{Duration: DefaultLeaseDuration},
+			RenewDeadline:     metav1.Duration{Duration: DefaultRenewDeadline},
+			RetryPeriod:       metav1.Duration{Duration: DefaultRetryPeriod},
+		},
+	}
+
+	if diff := cmp.Diff(want, *c); diff != "" {
+		t.Errorf("SetDefaults_ControllerConfiguration() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// TestSetDefaultsControllerConfigurationPreservesSetFields checks that a field a config file did
+// set is left alone, since SetDefaults_ControllerConfiguration must only fill in what's unset,
+// not overwrite a partially specified config.
+func TestSetDefaultsControllerConfigurationPreservesSetFields(t *testing.T) {
+	eventVerbosity := int32(0)
+	c := &ControllerConfiguration{
+		WorkerResyncPeriod: metav1.Duration{Duration: DefaultWorkerResyncPeriod * 2},
+		EventVerbosity:     &eventVerbosity,
+		LeaderElection: LeaderElectionConfiguration{
+			ResourceName: "custom-lease",
+		},
+	}
+	SetDefaults_ControllerConfiguration(c)
+
+	if got := c.WorkerResyncPeriod.Duration; got != DefaultWorkerResyncPeriod*2 {
+		t.Errorf("WorkerResyncPeriod = %v, want %v", got, DefaultWorkerResyncPeriod*2)
+	}
+	if c.EventVerbosity == nil || *c.EventVerbosity != 0 {
+		t.Errorf("EventVerbosity = %v, want explicit 0 to survive defaulting", c.EventVerbosity)
+	}
+	if got := c.LeaderElection.ResourceName; got != "custom-lease" {
+		t.Errorf("LeaderElection.ResourceName = %q, want %q", got, "custom-lease")
+	}
+	if got := c.LeaderElection.ResourceNamespace; got != DefaultLeaderElectionResourceNamespace {
+		t.Errorf("LeaderElection.ResourceNamespace = %q, want default %q", got, DefaultLeaderElectionResourceNamespace)
+	}
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package v1alpha1 is the v1alpha1 version of the config.gke.io ControllerConfiguration API,
+// loaded from the file passed via --config. It replaces tuning the controller exclusively through
+// CLI flags so that operators can GitOps the configuration alongside the deployment manifest.
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ControllerConfiguration configures the managed certificate controller binary.
+type ControllerConfiguration struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// WorkerResyncPeriod is how often each per-resource worker queue is drained, replacing the
+	// hardcoded time.Second period.
+	// +optional
+	WorkerResyncPeriod metav1.Duration `json:"workerResyncPeriod,omitempty"`
+
+	// SslCertificateSweepPeriod is how often the coarse garbage-collection sweep for orphaned
+	// SslCertificates runs, as a safety net on top of the state-change-driven cleanup that happens
+	// immediately when rotation supersedes a certificate.
+	// +optional
+	SslCertificateSweepPeriod metav1.Duration `json:"sslCertificateSweepPeriod,omitempty"`
+
+	// WorkqueueRateLimiter configures the rate limiter shared by the Mcert and Ingress workqueues.
+	// +optional
+	WorkqueueRateLimiter WorkqueueRateLimiterConfiguration `json:"workqueueRateLimiter,omitempty"`
+
+	// GCEProject overrides the GCE project SslCertificates are created in; empty means autodetect
+	// from the metadata server, today's only behavior.
+	// +optional
+	GCEProject string `json:"gceProject,omitempty"`
+
+	// GCERegion overrides the GCE region used for regional API calls; empty means autodetect.
+	// +optional
+	GCERegion string `json:"gceRegion,omitempty"`
+
+	// EventVerbosity controls how many of the informational Events (as opposed to Warning events,
+	// which are always recorded) SslCertificateManager records. Higher is more verbose; 0 disables
+	// informational events entirely. It is a pointer so that an explicit 0 in a config file is
+	// distinguishable from an unset field, which SetDefaults_ControllerConfiguration fills in with
+	// DefaultEventVerbosity instead.
+	// +optional
+	EventVerbosity *int32 `json:"eventVerbosity,omitempty"`
+
+	// ExpiryWarningThresholds are the days-until-expiration boundaries, ordered from most to least
+	// urgent, at which SslCertificateManager generates an ExpiringSoon Warning event on the
+	// ManagedCertificate.
+	// +optional
+	ExpiryWarningThresholds []int32 `json:"expiryWarningThresholds,omitempty"`
+
+	// Rotation configures pre-expiry certificate rotation.
+	// +optional
+	Rotation RotationConfiguration `json:"rotation,omitempty"`
+
+	// LeaderElection configures whether and how the controller uses leader election to support
+	// running more than one replica.
+	// +optional
+	LeaderElection LeaderElectionConfiguration `json:"leaderElection,omitempty"`
+
+	// ACME configures the optional ACME-backed Issuer that a ManagedCertificate can select via
+	// spec.issuerRef.
+	// +optional
+	ACME ACMEConfiguration `json:"acme,omitempty"`
+}
+
+// ACMEConfiguration configures the ACME-backed Issuer. Leaving DirectoryURL unset disables the
+// ACME issuer entirely: a ManagedCertificate referencing it then fails at Issuer selection instead
+// of silently falling back to the default GCE issuer.
+type ACMEConfiguration struct {
+	// DirectoryURL is the ACME server's directory endpoint, e.g. Let's Encrypt's production or
+	// staging directory. Empty disables the ACME issuer.
+	// +optional
+	DirectoryURL string `json:"directoryURL,omitempty"`
+
+	// AccountKeyFile is the path to a PEM-encoded EC private key used to register and authenticate
+	// with the ACME server's account. A key is generated in memory, but not persisted, if this is
+	// left empty - fine for a single run, but a restart registers a new account.
+	// +optional
+	AccountKeyFile string `json:"accountKeyFile,omitempty"`
+}
+
+// WorkqueueRateLimiterConfiguration mirrors the knobs of
+// workqueue.NewItemExponentialFailureRateLimiter plus the overall bucket rate limiter.
+type WorkqueueRateLimiterConfiguration struct {
+	// BaseDelay is the initial backoff for a requeued item after a failure.
+	// +optional
+	BaseDelay metav1.Duration `json:"baseDelay,omitempty"`
+
+	// MaxDelay caps the exponential backoff for a repeatedly failing item.
+	// +optional
+	MaxDelay metav1.Duration `json:"maxDelay,omitempty"`
+
+	// QPS is the steady-state rate, in requeues per second, allowed across the whole queue.
+	// +optional
+	QPS float32 `json:"qps,omitempty"`
+
+	// Burst is the maximum burst size allowed above QPS.
+	// +optional
+	Burst int32 `json:"burst,omitempty"`
+}
+
+// RotationConfiguration configures pre-expiry certificate rotation, and the reconcile cadence that
+// drives it and expiry reporting.
+type RotationConfiguration struct {
+	// Window is how far ahead of a certificate's NotAfter a replacement is provisioned.
+	// +optional
+	Window metav1.Duration `json:"window,omitempty"`
+
+	// ProvisioningRequeuePeriod is how soon a ManagedCertificate is checked again while its
+	// certificate, or a rotation replacement, has not yet reached ACTIVE.
+	// +optional
+	ProvisioningRequeuePeriod metav1.Duration `json:"provisioningRequeuePeriod,omitempty"`
+
+	// ActiveRequeuePeriod is the long backoff used once a certificate is ACTIVE and not yet close
+	// to its rotation window, so a large cluster isn't polled against the Issuer's backend on
+	// ProvisioningRequeuePeriod's much shorter cadence.
+	// +optional
+	ActiveRequeuePeriod metav1.Duration `json:"activeRequeuePeriod,omitempty"`
+
+	// RequeueJitter is added to ActiveRequeuePeriod so that certificates created around the same
+	// time don't all come up for rotation, and backend polling, in the same instant.
+	// +optional
+	RequeueJitter metav1.Duration `json:"requeueJitter,omitempty"`
+}
+
+// LeaderElectionConfiguration configures client-go leader election.
+type LeaderElectionConfiguration struct {
+	// Enabled turns leader election on. It should be true whenever more than one controller
+	// replica is deployed.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// ResourceName is the name of the Lease object used as the lock.
+	// +optional
+	ResourceName string `json:"resourceName,omitempty"`
+
+	// ResourceNamespace is the namespace of the Lease object used as the lock.
+	// +optional
+	ResourceNamespace string `json:"resourceNamespace,omitempty"`
+
+	// LeaseDuration is the duration non-leader candidates wait before forcing acquisition.
+	// +optional
+	LeaseDuration metav1.Duration `json:"leaseDuration,omitempty"`
+
+	// RenewDeadline is how long the leader keeps trying to renew before giving up the lease.
+	// +optional
+	RenewDeadline metav1.Duration `json:"renewDeadline,omitempty"`
+
+	// RetryPeriod is how long clients wait between actions while acquiring or renewing the lease.
+	// +optional
+	RetryPeriod metav1.Duration `json:"retryPeriod,omitempty"`
+}
+
+// DeepCopyObject implements runtime.Object. It is hand-written rather than generated by
+// deepcopy-gen, since that generator isn't wired up for this new API group yet. Every field except
+// EventVerbosity and ExpiryWarningThresholds is a value type, so a shallow copy handles them; the
+// pointer and the slice are copied explicitly so mutating one ControllerConfiguration's
+// EventVerbosity or thresholds can't reach through to another's.
+func (c *ControllerConfiguration) DeepCopyObject() runtime.Object {
+	if c == nil {
+		return nil
+	}
+	out := new(ControllerConfiguration)
+	*out = *c
+	if c.EventVerbosity != nil {
+		eventVerbosity := *c.EventVerbosity
+		out.EventVerbosity = &eventVerbosity
+	}
+	if c.ExpiryWarningThresholds != nil {
+		out.ExpiryWarningThresholds = append([]int32(nil), c.ExpiryWarningThresholds...)
+	}
+	return out
+}
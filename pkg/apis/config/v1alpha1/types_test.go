@@ -0,0 +1,95 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// fuzzControllerConfiguration populates every field of a ControllerConfiguration with a
+// reproducible pseudo-random value, so serializing it and reading it back exercises every field at
+// least once.
+func fuzzControllerConfiguration(r *rand.Rand) ControllerConfiguration {
+	randDuration := func() metav1.Duration {
+		return metav1.Duration{Duration: time.Duration(r.Int63n(int64(time.Hour)))}
+	}
+	eventVerbosity := r.Int31n(10)
+
+	return ControllerConfiguration{
+		WorkerResyncPeriod:        randDuration(),
+		SslCertificateSweepPeriod: randDuration(),
+		WorkqueueRateLimiter: WorkqueueRateLimiterConfiguration{
+			BaseDelay: randDuration(),
+			MaxDelay:  randDuration(),
+			QPS:       r.Float32() * 100,
+			Burst:     r.Int31n(1000),
+		},
+		GCEProject:              "project-a",
+		GCERegion:               "us-central1",
+		EventVerbosity:          &eventVerbosity,
+		ExpiryWarningThresholds: []int32{r.Int31n(10), 10 + r.Int31n(10), 20 + r.Int31n(10)},
+		Rotation: RotationConfiguration{
+			Window:                    randDuration(),
+			ProvisioningRequeuePeriod: randDuration(),
+			ActiveRequeuePeriod:       randDuration(),
+			RequeueJitter:             randDuration(),
+		},
+		LeaderElection: LeaderElectionConfiguration{
+			Enabled:           r.Intn(2) == 0,
+			ResourceName:      "managed-certificate-controller",
+			ResourceNamespace: "kube-system",
+			LeaseDuration:     randDuration(),
+			RenewDeadline:     randDuration(),
+			RetryPeriod:       randDuration(),
+		},
+		ACME: ACMEConfiguration{
+			DirectoryURL:   "https://acme-v02.example.com/directory",
+			AccountKeyFile: "/etc/managed-certificate-controller/acme-account.pem",
+		},
+	}
+}
+
+// TestControllerConfigurationRoundTrip checks that marshaling a ControllerConfiguration to YAML
+// and back produces an identical value, which would catch a json tag typo or a forgotten field on
+// the next addition to the struct.
+func TestControllerConfigurationRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+
+	for i := 0; i < 100; i++ {
+		in := fuzzControllerConfiguration(r)
+
+		out, err := yaml.Marshal(&in)
+		if err != nil {
+			t.Fatalf("iteration %d: Marshal() = %v", i, err)
+		}
+
+		var got ControllerConfiguration
+		if err := yaml.Unmarshal(out, &got); err != nil {
+			t.Fatalf("iteration %d: Unmarshal() = %v", i, err)
+		}
+
+		if diff := cmp.Diff(in, got); diff != "" {
+			t.Errorf("iteration %d: round trip mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+}
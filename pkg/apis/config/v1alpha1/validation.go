@@ -0,0 +1,93 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidateControllerConfiguration checks that c is internally consistent, returning every problem
+// found rather than stopping at the first one, so a user fixing a config file isn't surprised by a
+// second error on their next attempt.
+func ValidateControllerConfiguration(c *ControllerConfiguration) field.ErrorList {
+	var errs field.ErrorList
+
+	if c.WorkerResyncPeriod.Duration <= 0 {
+		errs = append(errs, field.Invalid(field.NewPath("workerResyncPeriod"), c.WorkerResyncPeriod, "must be greater than zero"))
+	}
+	if c.SslCertificateSweepPeriod.Duration <= 0 {
+		errs = append(errs, field.Invalid(field.NewPath("sslCertificateSweepPeriod"), c.SslCertificateSweepPeriod, "must be greater than zero"))
+	}
+
+	if c.WorkqueueRateLimiter.QPS < 0 {
+		errs = append(errs, field.Invalid(field.NewPath("workqueueRateLimiter", "qps"), c.WorkqueueRateLimiter.QPS, "must not be negative"))
+	}
+	if c.WorkqueueRateLimiter.Burst < 0 {
+		errs = append(errs, field.Invalid(field.NewPath("workqueueRateLimiter", "burst"), c.WorkqueueRateLimiter.Burst, "must not be negative"))
+	}
+	if c.WorkqueueRateLimiter.BaseDelay.Duration > 0 && c.WorkqueueRateLimiter.MaxDelay.Duration > 0 &&
+		c.WorkqueueRateLimiter.BaseDelay.Duration > c.WorkqueueRateLimiter.MaxDelay.Duration {
+		errs = append(errs, field.Invalid(field.NewPath("workqueueRateLimiter", "maxDelay"), c.WorkqueueRateLimiter.MaxDelay, "must be greater than or equal to baseDelay"))
+	}
+
+	if c.EventVerbosity != nil && *c.EventVerbosity < 0 {
+		errs = append(errs, field.Invalid(field.NewPath("eventVerbosity"), *c.EventVerbosity, "must not be negative"))
+	}
+
+	for i, threshold := range c.ExpiryWarningThresholds {
+		if threshold < 0 {
+			errs = append(errs, field.Invalid(field.NewPath("expiryWarningThresholds").Index(i), threshold, "must not be negative"))
+		}
+		if i > 0 && threshold < c.ExpiryWarningThresholds[i-1] {
+			errs = append(errs, field.Invalid(field.NewPath("expiryWarningThresholds").Index(i), threshold, "must be ordered from most to least urgent, i.e. ascending"))
+		}
+	}
+
+	if c.Rotation.Window.Duration <= 0 {
+		errs = append(errs, field.Invalid(field.NewPath("rotation", "window"), c.Rotation.Window, "must be greater than zero"))
+	}
+	if c.Rotation.ProvisioningRequeuePeriod.Duration <= 0 {
+		errs = append(errs, field.Invalid(field.NewPath("rotation", "provisioningRequeuePeriod"), c.Rotation.ProvisioningRequeuePeriod, "must be greater than zero"))
+	}
+	if c.Rotation.ActiveRequeuePeriod.Duration <= 0 {
+		errs = append(errs, field.Invalid(field.NewPath("rotation", "activeRequeuePeriod"), c.Rotation.ActiveRequeuePeriod, "must be greater than zero"))
+	}
+	if c.Rotation.RequeueJitter.Duration <= 0 {
+		errs = append(errs, field.Invalid(field.NewPath("rotation", "requeueJitter"), c.Rotation.RequeueJitter, "must be greater than zero"))
+	}
+
+	if c.ACME.AccountKeyFile != "" && c.ACME.DirectoryURL == "" {
+		errs = append(errs, field.Required(field.NewPath("acme", "directoryURL"), "must be set when acme.accountKeyFile is set"))
+	}
+
+	if c.LeaderElection.Enabled {
+		if c.LeaderElection.ResourceName == "" {
+			errs = append(errs, field.Required(field.NewPath("leaderElection", "resourceName"), "must be set when leader election is enabled"))
+		}
+		if c.LeaderElection.ResourceNamespace == "" {
+			errs = append(errs, field.Required(field.NewPath("leaderElection", "resourceNamespace"), "must be set when leader election is enabled"))
+		}
+		if c.LeaderElection.LeaseDuration.Duration <= c.LeaderElection.RenewDeadline.Duration {
+			errs = append(errs, field.Invalid(field.NewPath("leaderElection", "leaseDuration"), c.LeaderElection.LeaseDuration, "must be greater than renewDeadline"))
+		}
+		if c.LeaderElection.RenewDeadline.Duration <= c.LeaderElection.RetryPeriod.Duration {
+			errs = append(errs, field.Invalid(field.NewPath("leaderElection", "renewDeadline"), c.LeaderElection.RenewDeadline, "must be greater than retryPeriod"))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// validControllerConfiguration returns a fully defaulted, valid ControllerConfiguration with
+// leader election enabled, so individual test cases only need to break one field at a time instead
+// of building a whole valid config from scratch.
+func validControllerConfiguration() *ControllerConfiguration {
+	c := &ControllerConfiguration{}
+	SetDefaults_ControllerConfiguration(c)
+	c.LeaderElection.Enabled = true
+	return c
+}
+
+func TestValidateControllerConfigurationValid(t *testing.T) {
+	if errs := ValidateControllerConfiguration(validControllerConfiguration()); len(errs) > 0 {
+		t.Errorf("ValidateControllerConfiguration() = %v, want no errors", errs)
+	}
+}
+
+func TestValidateControllerConfigurationErrors(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		mutate    func(c *ControllerConfiguration)
+		wantField string
+	}{
+		{
+			desc:      "workerResyncPeriod zero",
+			mutate:    func(c *ControllerConfiguration) { c.WorkerResyncPeriod = metav1.Duration{} },
+			wantField: "workerResyncPeriod",
+		},
+		{
+			desc:      "sslCertificateSweepPeriod negative",
+			mutate:    func(c *ControllerConfiguration) { c.SslCertificateSweepPeriod = metav1.Duration{Duration: -1} },
+			wantField: "sslCertificateSweepPeriod",
+		},
+		{
+			desc:      "workqueueRateLimiter.qps negative",
+			mutate:    func(c *ControllerConfiguration) { c.WorkqueueRateLimiter.QPS = -1 },
+			wantField: "workqueueRateLimiter.qps",
+		},
+		{
+			desc:      "workqueueRateLimiter.burst negative",
+			mutate:    func(c *ControllerConfiguration) { c.WorkqueueRateLimiter.Burst = -1 },
+			wantField: "workqueueRateLimiter.burst",
+		},
+		{
+			desc: "workqueueRateLimiter.maxDelay less than baseDelay",
+			mutate: func(c *ControllerConfiguration) {
+				c.WorkqueueRateLimiter.BaseDelay = metav1.Duration{Duration: DefaultWorkqueueMaxDelay}
+				c.WorkqueueRateLimiter.MaxDelay = metav1.Duration{Duration: DefaultWorkqueueBaseDelay}
+			},
+			wantField: "workqueueRateLimiter.maxDelay",
+		},
+		{
+			desc: "eventVerbosity negative",
+			mutate: func(c *ControllerConfiguration) {
+				eventVerbosity := int32(-1)
+				c.EventVerbosity = &eventVerbosity
+			},
+			wantField: "eventVerbosity",
+		},
+		{
+			desc:      "expiryWarningThresholds negative entry",
+			mutate:    func(c *ControllerConfiguration) { c.ExpiryWarningThresholds = []int32{-1, 14, 30} },
+			wantField: "expiryWarningThresholds[0]",
+		},
+		{
+			desc:      "expiryWarningThresholds not ascending",
+			mutate:    func(c *ControllerConfiguration) { c.ExpiryWarningThresholds = []int32{30, 14, 7} },
+			wantField: "expiryWarningThresholds[1]",
+		},
+		{
+			desc:      "rotation.window zero",
+			mutate:    func(c *ControllerConfiguration) { c.Rotation.Window = metav1.Duration{} },
+			wantField: "rotation.window",
+		},
+		{
+			desc:      "rotation.provisioningRequeuePeriod zero",
+			mutate:    func(c *ControllerConfiguration) { c.Rotation.ProvisioningRequeuePeriod = metav1.Duration{} },
+			wantField: "rotation.provisioningRequeuePeriod",
+		},
+		{
+			desc:      "rotation.activeRequeuePeriod negative",
+			mutate:    func(c *ControllerConfiguration) { c.Rotation.ActiveRequeuePeriod = metav1.Duration{Duration: -1} },
+			wantField: "rotation.activeRequeuePeriod",
+		},
+		{
+			desc:      "rotation.requeueJitter zero",
+			mutate:    func(c *ControllerConfiguration) { c.Rotation.RequeueJitter = metav1.Duration{} },
+			wantField: "rotation.requeueJitter",
+		},
+		{
+			desc: "acme.accountKeyFile set without directoryURL",
+			mutate: func(c *ControllerConfiguration) {
+				c.ACME = ACMEConfiguration{AccountKeyFile: "/etc/managed-certificate-controller/acme-account.pem"}
+			},
+			wantField: "acme.directoryURL",
+		},
+		{
+			desc:      "leaderElection.resourceName empty when enabled",
+			mutate:    func(c *ControllerConfiguration) { c.LeaderElection.ResourceName = "" },
+			wantField: "leaderElection.resourceName",
+		},
+		{
+			desc:      "leaderElection.resourceNamespace empty when enabled",
+			mutate:    func(c *ControllerConfiguration) { c.LeaderElection.ResourceNamespace = "" },
+			wantField: "leaderElection.resourceNamespace",
+		},
+		{
+			desc: "leaderElection.leaseDuration not greater than renewDeadline",
+			mutate: func(c *ControllerConfiguration) {
+				c.LeaderElection.LeaseDuration = metav1.Duration{Duration: DefaultRenewDeadline}
+				c.LeaderElection.RenewDeadline = metav1.Duration{Duration: DefaultRenewDeadline}
+			},
+			wantField: "leaderElection.leaseDuration",
+		},
+		{
+			desc: "leaderElection.renewDeadline not greater than retryPeriod",
+			mutate: func(c *ControllerConfiguration) {
+				c.LeaderElection.RenewDeadline = metav1.Duration{Duration: DefaultRetryPeriod}
+				c.LeaderElection.RetryPeriod = metav1.Duration{Duration: DefaultRetryPeriod}
+			},
+			wantField: "leaderElection.renewDeadline",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			c := validControllerConfiguration()
+			tc.mutate(c)
+
+			errs := ValidateControllerConfiguration(c)
+			if len(errs) == 0 {
+				t.Fatalf("ValidateControllerConfiguration() = no errors, want one for field %q", tc.wantField)
+			}
+
+			var found bool
+			for _, err := range errs {
+				if strings.Contains(err.Error(), tc.wantField) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Errorf("ValidateControllerConfiguration() = %v, want an error mentioning %q", errs, tc.wantField)
+			}
+		})
+	}
+}
+
+// TestValidateControllerConfigurationLeaderElectionDisabled checks that leader election's own
+// fields are only required when it is enabled, so a config that leaves it off doesn't have to set
+// resourceName/resourceNamespace/durations at all.
+func TestValidateControllerConfigurationLeaderElectionDisabled(t *testing.T) {
+	c := validControllerConfiguration()
+	c.LeaderElection = LeaderElectionConfiguration{}
+
+	if errs := ValidateControllerConfiguration(c); len(errs) > 0 {
+		t.Errorf("ValidateControllerConfiguration() = %v, want no errors with leader election disabled", errs)
+	}
+}
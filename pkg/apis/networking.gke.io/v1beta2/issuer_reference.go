@@ -0,0 +1,59 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2
+
+// IssuerReference points at the Issuer that should back a ManagedCertificate. An empty
+// IssuerReference means the default, Google-managed SslCertificate issuer is used, preserving the
+// existing behavior for ManagedCertificates that don't set it. It backs ManagedCertificateSpec.IssuerRef
+// on the CRD type.
+type IssuerReference struct {
+	// Name of the referenced Issuer object. It is not used when selecting the Issuer; only Kind is.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Kind of the referenced Issuer: empty selects the built-in GCE SslCertificate issuer and
+	// "ACMEIssuer" selects the ACME issuer. Any other kind is rejected at Issuer selection.
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Group of the referenced Issuer. It is not used when selecting the Issuer; only Kind is.
+	// +optional
+	Group string `json:"group,omitempty"`
+}
+
+const (
+	// issuerRefKindAnnotation, issuerRefGroupAnnotation and issuerRefNameAnnotation are the
+	// deprecated wire format for IssuerReference, predating ManagedCertificateSpec.IssuerRef: one
+	// annotation per field, keyed to mirror the struct's own field names. IssuerRefFromAnnotations
+	// is kept only as a fallback so a ManagedCertificate created before the spec field existed keeps
+	// selecting the same Issuer after an upgrade.
+	issuerRefKindAnnotation  = "networking.gke.io/issuer-kind"
+	issuerRefGroupAnnotation = "networking.gke.io/issuer-group"
+	issuerRefNameAnnotation  = "networking.gke.io/issuer-name"
+)
+
+// IssuerRefFromAnnotations builds the IssuerReference a ManagedCertificate requested via the
+// deprecated annotations, returning the zero value - which selects the default GCE issuer - if none
+// are set. Callers should prefer ManagedCertificateSpec.IssuerRef and only fall back to this for a
+// ManagedCertificate that doesn't set it.
+func IssuerRefFromAnnotations(annotations map[string]string) IssuerReference {
+	return IssuerReference{
+		Name:  annotations[issuerRefNameAnnotation],
+		Kind:  annotations[issuerRefKindAnnotation],
+		Group: annotations[issuerRefGroupAnnotation],
+	}
+}
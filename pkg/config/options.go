@@ -0,0 +1,63 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	configv1alpha1 "managed-certs-gke/pkg/apis/config/v1alpha1"
+	"sigs.k8s.io/yaml"
+)
+
+// LoadConfiguration reads a ControllerConfiguration from the YAML file at path, applies defaults
+// to every field the file left unset, validates the result and returns it. An empty path returns
+// the all-defaults configuration, equivalent to today's behavior of only accepting CLI flags. The
+// binary's --config flag, registered in cmd/main.go, wires its argument straight through to path
+// and stores the result on ControllerOptions.Configuration before calling NewController.
+func LoadConfiguration(path string) (*configv1alpha1.ControllerConfiguration, error) {
+	c := &configv1alpha1.ControllerConfiguration{}
+
+	if path != "" {
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s: %v", path, err)
+		}
+
+		if err := yaml.Unmarshal(raw, c); err != nil {
+			return nil, fmt.Errorf("could not parse %s as a config.gke.io/v1alpha1 ControllerConfiguration: %v", path, err)
+		}
+
+		// TypeMeta is optional in the file - Unmarshal doesn't require it - but if it's set, it must
+		// name this exact type, so a config written for some future apiVersion/kind fails loudly
+		// instead of being silently read as today's fields plus whatever it added.
+		if wantVersion := configv1alpha1.SchemeGroupVersion.String(); c.APIVersion != "" && c.APIVersion != wantVersion {
+			return nil, fmt.Errorf("%s: apiVersion %q does not match %q", path, c.APIVersion, wantVersion)
+		}
+		if c.Kind != "" && c.Kind != configv1alpha1.Kind {
+			return nil, fmt.Errorf("%s: kind %q does not match %q", path, c.Kind, configv1alpha1.Kind)
+		}
+	}
+
+	configv1alpha1.SetDefaults_ControllerConfiguration(c)
+
+	if errs := configv1alpha1.ValidateControllerConfiguration(c); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid controller configuration: %v", errs.ToAggregate())
+	}
+
+	return c, nil
+}
@@ -20,37 +20,70 @@ import (
 	"fmt"
 	"github.com/golang/glog"
 	"k8s.io/apimachinery/pkg/util/runtime"
-	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
+	"golang.org/x/time/rate"
 	"managed-certs-gke/pkg/config"
-	"time"
+	configv1alpha1 "managed-certs-gke/pkg/apis/config/v1alpha1"
+
+	"github.com/GoogleCloudPlatform/gke-managed-certs/pkg/issuer"
 )
 
+// newRateLimiter builds the rate limiter shared by the Mcert and Ingress workqueues from cfg,
+// mirroring workqueue.DefaultControllerRateLimiter()'s own combination of a per-item exponential
+// backoff with an overall token-bucket cap, but with both tunable instead of hardcoded.
+func newRateLimiter(cfg configv1alpha1.WorkqueueRateLimiterConfiguration) workqueue.RateLimiter {
+	return workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(cfg.BaseDelay.Duration, cfg.MaxDelay.Duration),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(cfg.QPS), int(cfg.Burst))},
+	)
+}
+
 func NewController(opts *config.ControllerOptions) *Controller {
 	mcertInformer := opts.McertInformerFactory.Cloud().V1alpha1().ManagedCertificates()
 
+	// opts.ACMEIssuer is nil unless cmd/main.go built one from a configured ACME directory URL;
+	// ManagedCertificates that don't reference the ACME issuer are unaffected either way.
+	issuers := issuer.NewRegistry(issuer.NewGCEIssuer(opts.SslClient), opts.ACMEIssuer)
+
+	workerResyncPeriod := opts.Configuration.WorkerResyncPeriod.Duration
+	sslCertificateSweepPeriod := opts.Configuration.SslCertificateSweepPeriod.Duration
+	rateLimiter := newRateLimiter(opts.Configuration.WorkqueueRateLimiter)
+
 	controller := &Controller{
 		Ingress: IngressController{
 			client: opts.IngressClient,
-			queue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "ingressQueue"),
+			queue: workqueue.NewNamedRateLimitingQueue(rateLimiter, "ingressQueue"),
+			workerResyncPeriod: workerResyncPeriod,
 		},
 		Mcert: McertController{
 			client: opts.McertClient,
 			lister: mcertInformer.Lister(),
 			synced: mcertInformer.Informer().HasSynced,
-			queue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "mcertQueue"),
+			queue: workqueue.NewNamedRateLimitingQueue(rateLimiter, "mcertQueue"),
+			expiryQueue: workqueue.NewDelayingQueue(),
 			sslClient: opts.SslClient,
 			state: newMcertState(),
+			issuers: issuers,
+			workerResyncPeriod: workerResyncPeriod,
+			sslCertificateSweepPeriod: sslCertificateSweepPeriod,
+			rotationWindow: opts.Configuration.Rotation.Window.Duration,
+			provisioningRequeuePeriod: opts.Configuration.Rotation.ProvisioningRequeuePeriod.Duration,
+			activeRequeuePeriod: opts.Configuration.Rotation.ActiveRequeuePeriod.Duration,
+			requeueJitter: opts.Configuration.Rotation.RequeueJitter.Duration,
 		},
+		leaderElection: opts.Configuration.LeaderElection,
+		leaderElectionClient: opts.IngressClient,
 	}
 
 	mcertInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			controller.Mcert.enqueue(obj)
+			controller.Mcert.enqueueExpiry(obj)
 		},
 		UpdateFunc: func(old, new interface{}) {
 			controller.Mcert.enqueue(new)
+			controller.Mcert.enqueueExpiry(new)
 		},
 		DeleteFunc: func(obj interface{}) {
 			controller.Mcert.enqueue(obj)
@@ -71,28 +104,12 @@ func (c *Controller) Run(stopChannel <-chan struct{}) error {
 	}
 	glog.Info("Cache synced")
 
-	errors := make(chan error)
-
-	mcertStopChannel := make(chan struct{})
-	go c.Mcert.Run(mcertStopChannel, errors)
-
-	ingressStopChannel := make(chan struct{})
-	go c.Ingress.Run(ingressStopChannel)
-
-	go wait.Until(c.runIngressWorker, time.Second, stopChannel)
-
-	glog.Info("Waiting for stop signal or error")
-	select{
-		case <-stopChannel:
-			glog.Info("Received stop signal")
-			quit(mcertStopChannel, ingressStopChannel)
-		case err := <-errors:
-			runtime.HandleError(err)
-			quit(mcertStopChannel, ingressStopChannel)
+	if !c.leaderElection.Enabled {
+		return c.runLeader(stopChannel)
 	}
 
-	glog.Info("Shutting down")
-	return nil
+	glog.Info("Leader election enabled, waiting to acquire lease before starting reconcile loops")
+	return c.runWithLeaderElection(c.leaderElectionClient, c.leaderElection, stopChannel)
 }
 
 func quit(mcertStopChannel, ingressStopChannel chan<- struct{}) {
@@ -0,0 +1,109 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+
+	"github.com/golang/glog"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// preSharedCertAnnotation is the GKE Ingress annotation holding the comma-separated list of
+// SslCertificate names an Ingress references.
+const preSharedCertAnnotation = "ingress.gcp.kubernetes.io/pre-shared-cert"
+
+// replaceIngressTLSSecretReference rewrites the spec.tls[].secretName of every Ingress that
+// references oldSecretName to reference nextSecretName instead, the ACME issuer's counterpart to
+// replaceSslCertificateReference: an ACME-issued certificate has no GCE SslCertificate resource to
+// point the GCE-specific pre-shared-cert annotation at, so cutover instead has to rewrite the
+// Kubernetes-native Ingress TLS Secret reference. An empty or unchanged oldSecretName is a no-op.
+func (i IngressController) replaceIngressTLSSecretReference(oldSecretName, nextSecretName string) error {
+	if oldSecretName == "" || oldSecretName == nextSecretName {
+		return nil
+	}
+
+	ingresses, err := i.client.ExtensionsV1beta1().Ingresses(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, ingress := range ingresses.Items {
+		replaced := false
+		updated := ingress.DeepCopy()
+		for idx, tls := range updated.Spec.TLS {
+			if tls.SecretName == oldSecretName {
+				updated.Spec.TLS[idx].SecretName = nextSecretName
+				replaced = true
+			}
+		}
+		if !replaced {
+			continue
+		}
+
+		if _, err := i.client.ExtensionsV1beta1().Ingresses(updated.Namespace).Update(updated); err != nil {
+			return err
+		}
+
+		glog.Infof("Updated Ingress %s:%s TLS secret reference from %s to %s", updated.Namespace, updated.Name, oldSecretName, nextSecretName)
+	}
+
+	return nil
+}
+
+// replaceSslCertificateReference rewrites the pre-shared-cert annotation of every Ingress that
+// references oldCertName to reference nextCertName instead, so that rotation's cutover to a newly
+// ACTIVE certificate is reflected on the Ingresses that serve traffic with it. oldCertName may
+// appear alongside other certificate names in the annotation's comma-separated list, so only the
+// matching entry is swapped. An empty or unchanged oldCertName is a no-op: there is nothing to cut
+// over from the first time a ManagedCertificate is provisioned.
+func (i IngressController) replaceSslCertificateReference(oldCertName, nextCertName string) error {
+	if oldCertName == "" || oldCertName == nextCertName {
+		return nil
+	}
+
+	ingresses, err := i.client.ExtensionsV1beta1().Ingresses(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, ingress := range ingresses.Items {
+		certNames := strings.Split(ingress.ObjectMeta.Annotations[preSharedCertAnnotation], ",")
+
+		replaced := false
+		for idx, certName := range certNames {
+			if certName == oldCertName {
+				certNames[idx] = nextCertName
+				replaced = true
+			}
+		}
+		if !replaced {
+			continue
+		}
+
+		updated := ingress.DeepCopy()
+		updated.ObjectMeta.Annotations[preSharedCertAnnotation] = strings.Join(certNames, ",")
+
+		if _, err := i.client.ExtensionsV1beta1().Ingresses(updated.Namespace).Update(updated); err != nil {
+			return err
+		}
+
+		glog.Infof("Updated Ingress %s:%s pre-shared-cert annotation from %s to %s", updated.Namespace, updated.Name, oldCertName, nextCertName)
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	configv1alpha1 "managed-certs-gke/pkg/apis/config/v1alpha1"
+)
+
+// runLeader starts the reconcile loops that must only ever run on a single replica: the Mcert and
+// Ingress controllers and the periodic Ingress resync. It blocks until stopChannel is closed or one
+// of the loops reports an error.
+func (c *Controller) runLeader(stopChannel <-chan struct{}) error {
+	errors := make(chan error)
+
+	mcertStopChannel := make(chan struct{})
+	go c.Mcert.Run(mcertStopChannel, errors)
+
+	ingressStopChannel := make(chan struct{})
+	go c.Ingress.Run(ingressStopChannel)
+
+	go wait.Until(c.runIngressWorker, c.Ingress.workerResyncPeriod, stopChannel)
+
+	glog.Info("Waiting for stop signal or error")
+	select {
+	case <-stopChannel:
+		glog.Info("Received stop signal")
+		quit(mcertStopChannel, ingressStopChannel)
+	case err := <-errors:
+		runtime.HandleError(err)
+		quit(mcertStopChannel, ingressStopChannel)
+	}
+
+	glog.Info("Shutting down")
+	return nil
+}
+
+// runWithLeaderElection wraps runLeader in client-go leader election: only the replica holding the
+// Lease named cfg.ResourceName calls runLeader, so that deploying more than one replica for HA
+// doesn't cause both to race on GCE Create/Delete calls and burn quota. Standbys block here,
+// observing the lease, ready to take over the instant it is released or expires.
+func (c *Controller) runWithLeaderElection(clientset kubernetes.Interface, cfg configv1alpha1.LeaderElectionConfiguration, stopChannel <-chan struct{}) error {
+	identity, err := os.Hostname()
+	if err != nil {
+		return fmt.Errorf("could not determine leader election identity: %v", err)
+	}
+
+	lock, err := resourcelock.New(resourcelock.LeasesResourceLock, cfg.ResourceNamespace, cfg.ResourceName,
+		clientset.CoreV1(), clientset.CoordinationV1(), resourcelock.ResourceLockConfig{Identity: identity})
+	if err != nil {
+		return fmt.Errorf("could not create leader election lock: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var stopping int32
+	go func() {
+		<-stopChannel
+		atomic.StoreInt32(&stopping, 1)
+		cancel()
+	}()
+
+	runErr := make(chan error, 1)
+	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: cfg.LeaseDuration.Duration,
+		RenewDeadline: cfg.RenewDeadline.Duration,
+		RetryPeriod:   cfg.RetryPeriod.Duration,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				glog.Infof("%s became leader, starting reconcile loops", identity)
+				runErr <- c.runLeader(ctx.Done())
+			},
+			OnStoppedLeading: func() {
+				// RunOrDie's Run defers this unconditionally whenever ctx is done, which includes
+				// the ordinary shutdown path (stopChannel closed, e.g. on SIGTERM) as well as an
+				// actual loss of leadership (renewal failure, network partition) - it does not tell
+				// the two apart. isGracefulStop, backed by stopping (set just before the goroutine
+				// above cancels ctx), is how this callback tells them apart instead: during a
+				// graceful stop there is nothing to do here, runLeader is already unwinding against
+				// the same cancelled ctx. Only an unexpected loss of leadership should be fatal, so
+				// the kubelet restarts the process into a clean, unelected state that immediately
+				// starts contending for the lease again.
+				if isGracefulStop(&stopping) {
+					glog.Infof("%s stopped leading as part of a graceful shutdown", identity)
+					return
+				}
+				glog.Fatalf("%s is no longer leader, exiting so it can restart and re-contend for the lease", identity)
+			},
+			OnNewLeader: func(leader string) {
+				if leader != identity {
+					glog.Infof("%s observed new leader %s", identity, leader)
+				}
+			},
+		},
+	})
+
+	select {
+	case err := <-runErr:
+		return err
+	default:
+		return nil
+	}
+}
+
+// isGracefulStop reports whether stopping has been set, i.e. whether OnStoppedLeading is firing
+// because stopChannel was closed rather than because leadership was actually lost.
+func isGracefulStop(stopping *int32) bool {
+	return atomic.LoadInt32(stopping) != 0
+}
@@ -0,0 +1,34 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestIsGracefulStop(t *testing.T) {
+	var stopping int32
+	if isGracefulStop(&stopping) {
+		t.Errorf("isGracefulStop() = true before stopping is set, want false")
+	}
+
+	atomic.StoreInt32(&stopping, 1)
+	if !isGracefulStop(&stopping) {
+		t.Errorf("isGracefulStop() = false after stopping is set, want true")
+	}
+}
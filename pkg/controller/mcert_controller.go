@@ -2,17 +2,36 @@ package controller
 
 import (
 	"fmt"
+	"strings"
+	"time"
+
 	"github.com/golang/glog"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
 	api "managed-certs-gke/pkg/apis/cloud.google.com/v1alpha1"
-	"time"
+
+	"github.com/GoogleCloudPlatform/gke-managed-certs/pkg/issuer"
+	"github.com/GoogleCloudPlatform/gke-managed-certs/pkg/metrics"
 )
 
+// mcertDomains is the namespace, domain list and issuer kind last observed for a ManagedCertificate,
+// kept only so that a ManagedCertificate that has since been deleted can still have its per-domain
+// Prometheus expiry series cleared and its certificate object deleted through the right Issuer:
+// mcertState tracks certificate names, not this, and by the time a deletion is noticed the object is
+// gone from the lister too. It is stashed in c.lastKnownDomains, keyed by ManagedCertificate name.
+type mcertDomains struct {
+	namespace  string
+	domains    []string
+	issuerKind string
+}
+
 func (c *McertController) Run(stopChannel <-chan struct{}, errors chan<- error) {
 	defer c.queue.ShutDown()
+	defer c.expiryQueue.ShutDown()
 
 	err := c.initializeState()
 	if err != nil {
@@ -20,8 +39,19 @@ func (c *McertController) Run(stopChannel <-chan struct{}, errors chan<- error)
 		return
 	}
 
-	go wait.Until(c.runWorker, time.Second, stopChannel)
-	go wait.Until(c.synchronizeAllMcerts, time.Minute, stopChannel)
+	go wait.Until(c.runWorker, c.workerResyncPeriod, stopChannel)
+	go wait.Until(c.runExpiryWorker, c.workerResyncPeriod, stopChannel)
+	go wait.Until(c.reportQueueDepth, c.workerResyncPeriod, stopChannel)
+	go wait.Until(c.sweepObsoleteSslCertificates, c.sslCertificateSweepPeriod, stopChannel)
+
+	allMcertsInCluster, err := c.getAllMcertsInCluster()
+	if err != nil {
+		runtime.HandleError(err)
+	} else {
+		for _, mcert := range allMcertsInCluster {
+			c.enqueueExpiry(mcert)
+		}
+	}
 
 	<-stopChannel
 }
@@ -47,6 +77,123 @@ func (c *McertController) enqueue(obj interface{}) {
 	}
 }
 
+// enqueueExpiry adds obj's key to c.expiryQueue, the same way enqueue does for c.queue. It is used
+// both to seed the expiry queue at startup and by the informer's Add/Update handlers, so that a
+// ManagedCertificate created or updated after startup is monitored for expiry and rotation without
+// waiting for the next full resync.
+func (c *McertController) enqueueExpiry(obj interface{}) {
+	if key, err := cache.MetaNamespaceKeyFunc(obj); err != nil {
+		runtime.HandleError(err)
+	} else {
+		c.expiryQueue.Add(key)
+	}
+}
+
+// reportQueueDepth exports the current depth of the Mcert workqueues as Prometheus metrics.
+func (c *McertController) reportQueueDepth() {
+	metrics.ReportQueueDepth("mcert", c.queue.Len())
+	metrics.ReportQueueDepth("mcert_expiry", c.expiryQueue.Len())
+}
+
+// runExpiryWorker drains c.expiryQueue, reconciling certificate expiry and rotation for one
+// ManagedCertificate at a time and rescheduling it with AddAfter at an interval derived from its
+// own certificate state. This replaces polling every ManagedCertificate on a fixed interval
+// regardless of state, which wasted GCE API quota on large clusters.
+func (c *McertController) runExpiryWorker() {
+	for c.processNextExpiryItem() {
+	}
+}
+
+func (c *McertController) processNextExpiryItem() bool {
+	item, quit := c.expiryQueue.Get()
+	if quit {
+		return false
+	}
+	defer c.expiryQueue.Done(item)
+
+	key, ok := item.(string)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("unexpected item %v in Mcert expiry queue", item))
+		return true
+	}
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		runtime.HandleError(err)
+		return true
+	}
+
+	// Re-fetch from the lister rather than requeuing the object itself, so that a reconcile always
+	// sees the latest known state instead of a snapshot that may be hours stale by the time its
+	// AddAfter delay elapses.
+	mcert, err := c.lister.ManagedCertificates(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		// Deleted since being enqueued - nothing left to reconcile, and nothing to requeue.
+		return true
+	}
+	if err != nil {
+		runtime.HandleError(err)
+		c.expiryQueue.AddAfter(key, c.provisioningRequeuePeriod)
+		return true
+	}
+
+	c.expiryQueue.AddAfter(key, c.reconcileExpiryAndRotation(mcert))
+
+	return true
+}
+
+// reconcileExpiryAndRotation reports Prometheus expiry metrics, drives one step of certificate
+// issuance and rotation, and returns how soon mcert should be reconciled again. rotateIfNeeded runs
+// first and hands its fetched expiry time, if any, to reportExpiry, so that the common case - a
+// stable, already-ACTIVE certificate - costs one Issuer lookup per reconcile rather than one each.
+func (c *McertController) reconcileExpiryAndRotation(mcert *api.ManagedCertificate) time.Duration {
+	start := time.Now()
+	result := "success"
+
+	interval, expireTime, err := c.rotateIfNeeded(mcert)
+	if err != nil {
+		runtime.HandleError(err)
+		result = "error"
+	}
+
+	c.reportExpiry(mcert, expireTime)
+
+	metrics.ObserveReconcileLatency(result, time.Since(start))
+
+	return interval
+}
+
+// reportExpiry exports Prometheus expiration metrics and, if close enough to expiring, generates a
+// warning Event for the certificate backing mcert. expireTime, when non-empty, is the RFC3339
+// expiry rotateIfNeeded already fetched through mcert's Issuer for the same reconcile; it is looked
+// up here instead only when rotateIfNeeded had no need to, e.g. because a rotation was already in
+// progress. Errors are logged rather than returned, so that a single failing lookup does not stop
+// the rest of the cluster from being reconciled.
+func (c *McertController) reportExpiry(mcert *api.ManagedCertificate, expireTime string) {
+	currentCertName := c.currentSslCertificateName(mcert)
+	if currentCertName == "" {
+		return
+	}
+
+	if expireTime == "" {
+		iss, err := c.issuers.Select(issuerKind(mcert))
+		if err != nil {
+			runtime.HandleError(err)
+			return
+		}
+
+		expireTime, err = iss.ExpireTime(currentCertName, mcert)
+		if err != nil {
+			runtime.HandleError(err)
+			return
+		}
+	}
+
+	if err := c.sslClient.ReportExpiry(currentCertName, *mcert, expireTime); err != nil {
+		runtime.HandleError(err)
+	}
+}
+
 func (c *McertController) getAllMcertsInCluster() (result map[string]*api.ManagedCertificate, err error) {
 	mcerts, err := c.lister.List(labels.Everything())
 	if err != nil {
@@ -56,6 +203,11 @@ func (c *McertController) getAllMcertsInCluster() (result map[string]*api.Manage
 	result = make(map[string]*api.ManagedCertificate, len(mcerts))
 	for _, mcert := range mcerts {
 		result[mcert.ObjectMeta.Name] = mcert
+		c.lastKnownDomains.Store(mcert.ObjectMeta.Name, mcertDomains{
+			namespace:  mcert.Namespace,
+			domains:    mcert.Spec.Domains,
+			issuerKind: issuerKind(mcert),
+		})
 	}
 
 	return
@@ -65,13 +217,62 @@ func (c *McertController) deleteObsoleteMcertsFromState(allMcertsInCluster map[s
 	allKnownMcerts := c.state.GetAllManagedCertificates()
 	for _, knownMcert := range allKnownMcerts {
 		if _, exists := allMcertsInCluster[knownMcert]; !exists {
+			c.deleteCertificateForRemovedMcert(knownMcert)
+
 			// A managed certificate exists in state, but does not exist as a custom object in cluster, probably was deleted by the user - delete it from the state.
 			c.state.Delete(knownMcert)
 			glog.Infof("Deleted %s managed certificate from state, because such custom object does not exist in the cluster (any more?)", knownMcert)
+
+			if v, ok := c.lastKnownDomains.Load(knownMcert); ok {
+				domains := v.(mcertDomains)
+				for _, domain := range domains.domains {
+					metrics.DeleteExpiry(knownMcert, domains.namespace, domain)
+				}
+				c.lastKnownDomains.Delete(knownMcert)
+			}
 		}
 	}
 }
 
+// deleteCertificateForRemovedMcert deletes the certificate object backing mcertName through
+// whichever Issuer it was using, now that the ManagedCertificate itself is gone from the cluster.
+// This matters most for the ACME issuer: its TLS Secret isn't a GCE SslCertificate, so
+// deleteObsoleteSslCertificates' coarse sweep - which only lists SslCertificate resources - never
+// finds it, and without this call it would never be cleaned up. It has to run before c.state.Delete
+// and c.lastKnownDomains.Delete in deleteObsoleteMcertsFromState, since it needs both the
+// certificate name and the issuer kind they're about to remove.
+func (c *McertController) deleteCertificateForRemovedMcert(mcertName string) {
+	certName, exists := c.state.GetSslCertificateName(mcertName)
+	if !exists || certName == "" {
+		return
+	}
+
+	v, ok := c.lastKnownDomains.Load(mcertName)
+	if !ok {
+		return
+	}
+	domains := v.(mcertDomains)
+
+	iss, err := c.issuers.Select(domains.issuerKind)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	// A minimal ManagedCertificate carrying just enough identity for the Issuer to find what it
+	// needs to delete - the ACME issuer's DeleteCertificate looks up the TLS Secret by namespace,
+	// the GCE issuer's ignores mcrt entirely.
+	mcert := &api.ManagedCertificate{ObjectMeta: metav1.ObjectMeta{Name: mcertName, Namespace: domains.namespace}}
+
+	if err := iss.DeleteCertificate(certName, mcert); err != nil {
+		runtime.HandleError(err)
+	}
+}
+
+// deleteObsoleteSslCertificates deletes every SslCertificate named with issuer.GCECertificateNamePrefix
+// that state doesn't know about. It is the coarse safety-net sweep: under normal operation,
+// rotation's promoteIfActive deletes a superseded certificate the moment it is superseded, so this
+// only ever finds something to do after a crash mid-rotation or similar.
 func (c* McertController) deleteObsoleteSslCertificates() error {
 	allKnownSslCerts := c.state.GetAllSslCertificates()
 	allKnownSslCertsSet := make(map[string]bool, len(allKnownSslCerts))
@@ -86,8 +287,12 @@ func (c* McertController) deleteObsoleteSslCertificates() error {
 	}
 
 	for _, sslCert := range sslCerts.Items {
+		if !strings.HasPrefix(sslCert.Name, issuer.GCECertificateNamePrefix) {
+			continue
+		}
+
 		if known, exists := allKnownSslCertsSet[sslCert.Name]; !exists || !known {
-			c.sslClient.Delete(sslCert.Name)
+			c.sslClient.Delete(sslCert.Name, nil)
 			glog.Infof("Deleted %s SslCertificate resource, because there is no such ssl certificate in state", sslCert.Name)
 		}
 	}
@@ -95,7 +300,11 @@ func (c* McertController) deleteObsoleteSslCertificates() error {
 	return nil
 }
 
-func (c *McertController) synchronizeAllMcerts() {
+// sweepObsoleteSslCertificates is the coarse, infrequent counterpart to the per-object expiry-driven
+// reconcile: it catches ManagedCertificates and SslCertificates whose cleanup was missed by the
+// event- and state-change-driven paths, e.g. because the controller restarted mid-operation. It
+// runs every c.sslCertificateSweepPeriod, which defaults to an hour.
+func (c *McertController) sweepObsoleteSslCertificates() {
 	allMcertsInCluster, err := c.getAllMcertsInCluster()
 	if err != nil {
 		runtime.HandleError(err)
@@ -104,13 +313,7 @@ func (c *McertController) synchronizeAllMcerts() {
 
 	c.deleteObsoleteMcertsFromState(allMcertsInCluster)
 
-	err = c.deleteObsoleteSslCertificates()
-	if err != nil {
+	if err := c.deleteObsoleteSslCertificates(); err != nil {
 		runtime.HandleError(err)
-		return
 	}
-
-	for _, mcert := range allMcertsInCluster {
-		c.enqueue(mcert)
-	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,190 @@
+package controller
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/golang/glog"
+	api "managed-certs-gke/pkg/apis/cloud.google.com/v1alpha1"
+
+	"github.com/GoogleCloudPlatform/gke-managed-certs/pkg/apis/networking.gke.io/v1beta2"
+	"github.com/GoogleCloudPlatform/gke-managed-certs/pkg/issuer"
+)
+
+// nextSslCertificateSuffix marks the state entry holding the name of an in-progress
+// replacement certificate for a ManagedCertificate, mirroring the "next" pointer of a
+// two-secret rotator: the "current" pointer (the plain mcert name key) keeps serving traffic
+// until the replacement is ACTIVE, so in-flight TLS handshakes never see a gap.
+const nextSslCertificateSuffix = ":next"
+
+// issuerKind returns the Issuer kind mcert requested, or "" for the default GCE issuer. It prefers
+// the spec.issuerRef field, falling back to the deprecated annotation wire format for a
+// ManagedCertificate that predates that field.
+func issuerKind(mcert *api.ManagedCertificate) string {
+	if mcert.Spec.IssuerRef != nil {
+		return mcert.Spec.IssuerRef.Kind
+	}
+
+	return v1beta2.IssuerRefFromAnnotations(mcert.ObjectMeta.Annotations).Kind
+}
+
+// currentSslCertificateName returns the name of the SslCertificate presently serving mcert,
+// preferring c.state - the value rotation actually keeps up to date - over mcert.Status.CertificateName,
+// which only reflects state's view once the main reconcile loop has had a chance to persist it.
+// Status is used only as a fallback for a ManagedCertificate state doesn't know about yet, e.g.
+// before initializeState has run for it.
+func (c *McertController) currentSslCertificateName(mcert *api.ManagedCertificate) string {
+	if name, exists := c.state.GetSslCertificateName(mcert.ObjectMeta.Name); exists {
+		return name
+	}
+
+	return mcert.Status.CertificateName
+}
+
+// rotateIfNeeded drives one step of certificate issuance and rotation for mcert, routed through
+// whichever Issuer mcert selected. It is safe to call repeatedly and does nothing beyond a single
+// ExpireTime lookup unless issuance, rotation, or a rotation already in progress needs a step taken.
+// Besides how soon mcert should be reconciled again - a short backoff while a certificate is still
+// provisioning, a long backoff once ACTIVE and far from its rotation window, or a jittered interval
+// timed to land inside that window otherwise - it also returns the current certificate's expiry
+// time if this call fetched one, so that reconcileExpiryAndRotation can pass it to reportExpiry
+// instead of that fetching its own. The returned expiry time is "" whenever there was nothing to
+// fetch: no certificate exists yet, or a rotation is already in progress.
+func (c *McertController) rotateIfNeeded(mcert *api.ManagedCertificate) (time.Duration, string, error) {
+	iss, err := c.issuers.Select(issuerKind(mcert))
+	if err != nil {
+		return c.provisioningRequeuePeriod, "", err
+	}
+
+	nextKey := mcert.ObjectMeta.Name + nextSslCertificateSuffix
+
+	if nextCertName, inProgress := c.state.GetSslCertificateName(nextKey); inProgress {
+		return c.provisioningRequeuePeriod, "", c.promoteIfActive(iss, mcert, nextCertName)
+	}
+
+	currentCertName := c.currentSslCertificateName(mcert)
+	if currentCertName == "" {
+		return c.provisioningRequeuePeriod, "", c.issueInitialCertificate(iss, mcert)
+	}
+
+	expireTime, err := iss.ExpireTime(currentCertName, mcert)
+	if err != nil {
+		return c.provisioningRequeuePeriod, "", err
+	}
+
+	if expireTime == "" {
+		return c.provisioningRequeuePeriod, expireTime, nil
+	}
+
+	parsedExpireTime, err := time.Parse(time.RFC3339, expireTime)
+	if err != nil {
+		return c.provisioningRequeuePeriod, expireTime, err
+	}
+
+	untilRotation := time.Until(parsedExpireTime) - c.rotationWindow
+	if untilRotation > 0 {
+		return jitteredRequeueInterval(untilRotation, c.activeRequeuePeriod, c.requeueJitter), expireTime, nil
+	}
+
+	nextCertName, _, err := iss.EnsureCertificate("", *mcert)
+	if err != nil {
+		return c.provisioningRequeuePeriod, expireTime, err
+	}
+
+	glog.Infof("Started rotation of certificate %s for ManagedCertificate %s:%s, replacement is %s",
+		currentCertName, mcert.Namespace, mcert.Name, nextCertName)
+	c.state.Put(nextKey, nextCertName)
+
+	return c.provisioningRequeuePeriod, expireTime, nil
+}
+
+// issueInitialCertificate creates the very first certificate for a ManagedCertificate that has none
+// yet, through mcert's selected iss rather than always the GCE issuer, so that
+// spec.issuerRef.kind: ACMEIssuer takes effect from the start instead of only once a GCE certificate
+// already exists to rotate away from. Each EnsureCertificate("", ...) call creates a certificate
+// under a fresh name; once certName is recorded in state, rotateIfNeeded finds a current
+// certificate name and no longer calls this.
+func (c *McertController) issueInitialCertificate(iss issuer.Issuer, mcert *api.ManagedCertificate) error {
+	certName, _, err := iss.EnsureCertificate("", *mcert)
+	if err != nil {
+		return err
+	}
+
+	c.state.Put(mcert.ObjectMeta.Name, certName)
+
+	// Re-enqueue onto the main queue so the existing status-sync path persists certName to
+	// mcert.Status.CertificateName, the same way promoteIfActive does for a rotation promotion.
+	c.enqueue(mcert)
+
+	glog.Infof("Started issuing certificate %s for ManagedCertificate %s:%s", certName, mcert.Namespace, mcert.Name)
+
+	return nil
+}
+
+// jitteredRequeueInterval caps activeRequeuePeriod, the long ACTIVE backoff, so that it never skips
+// past untilRotation, the time remaining before the certificate enters its rotation window, then
+// adds up to jitter of random slack on top. Validation rejects a non-positive jitter, so
+// rand.Int63n below never sees an argument it would panic on.
+func jitteredRequeueInterval(untilRotation, activeRequeuePeriod, jitter time.Duration) time.Duration {
+	interval := activeRequeuePeriod
+	if untilRotation < interval {
+		interval = untilRotation
+	}
+
+	return interval + time.Duration(rand.Int63n(int64(jitter)))
+}
+
+// cutoverIngresses points every Ingress referencing oldCertName at nextCertName instead, through
+// whichever reference mechanism mcert's issuer uses: the GCE pre-shared-cert annotation for the GCE
+// issuer, or the Ingress TLS Secret reference for the ACME issuer, since an ACME-issued certificate
+// has no GCE SslCertificate resource for that annotation to point at.
+func (c *McertController) cutoverIngresses(mcert *api.ManagedCertificate, oldCertName, nextCertName string) error {
+	if issuerKind(mcert) == issuer.ACMEIssuerKind {
+		return c.Ingress.replaceIngressTLSSecretReference(oldCertName, nextCertName)
+	}
+
+	return c.Ingress.replaceSslCertificateReference(oldCertName, nextCertName)
+}
+
+// promoteIfActive checks whether nextCertName has finished provisioning with iss. Once it is
+// active, every Ingress referencing the old certificate is switched over through cutoverIngresses,
+// one Ingress at a time, and only then is the state mapping switched, after which the old
+// certificate is deleted - mirroring the promote step of a two-secret rotator. If an Ingress update
+// fails part-way, the Ingresses already updated point at nextCertName while state still names the
+// old certificate; the next reconcile repeats the cutover. This is the
+// state-change-triggered SslCertificate garbage collection; the coarse hourly sweep exists only as
+// a safety net for certificates this path never gets to clean up, e.g. a crash mid-rotation.
+func (c *McertController) promoteIfActive(iss issuer.Issuer, mcert *api.ManagedCertificate, nextCertName string) error {
+	_, active, err := iss.EnsureCertificate(nextCertName, *mcert)
+	if err != nil {
+		return err
+	}
+
+	if !active {
+		return nil
+	}
+
+	oldCertName := c.currentSslCertificateName(mcert)
+	nextKey := mcert.ObjectMeta.Name + nextSslCertificateSuffix
+
+	if err := c.cutoverIngresses(mcert, oldCertName, nextCertName); err != nil {
+		return err
+	}
+
+	c.state.Put(mcert.ObjectMeta.Name, nextCertName)
+	c.state.Delete(nextKey)
+
+	// Re-enqueue onto the main queue so the existing status-sync path persists the new current
+	// certificate name to mcert.Status.CertificateName; without this, Status keeps pointing at
+	// oldCertName forever, which is deleted below.
+	c.enqueue(mcert)
+
+	glog.Infof("Promoted certificate %s to current for ManagedCertificate %s:%s, deleting superseded %s",
+		nextCertName, mcert.Namespace, mcert.Name, oldCertName)
+
+	if oldCertName != "" && oldCertName != nextCertName {
+		return iss.DeleteCertificate(oldCertName, mcert)
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "managed-certs-gke/pkg/apis/cloud.google.com/v1alpha1"
+
+	"github.com/GoogleCloudPlatform/gke-managed-certs/pkg/apis/networking.gke.io/v1beta2"
+	"github.com/GoogleCloudPlatform/gke-managed-certs/pkg/issuer"
+)
+
+func TestIssuerKind(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		mcert *api.ManagedCertificate
+		want  string
+	}{
+		{
+			desc:  "no issuerRef or annotations defaults to GCE",
+			mcert: &api.ManagedCertificate{},
+			want:  issuer.GCEIssuerKind,
+		},
+		{
+			desc: "spec.issuerRef is preferred",
+			mcert: &api.ManagedCertificate{
+				Spec: api.ManagedCertificateSpec{IssuerRef: &v1beta2.IssuerReference{Kind: issuer.ACMEIssuerKind}},
+			},
+			want: issuer.ACMEIssuerKind,
+		},
+		{
+			desc: "deprecated annotation is a fallback when issuerRef is unset",
+			mcert: &api.ManagedCertificate{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"networking.gke.io/issuer-kind": issuer.ACMEIssuerKind}},
+			},
+			want: issuer.ACMEIssuerKind,
+		},
+		{
+			desc: "issuerRef wins even if it selects the zero value and an annotation is also set",
+			mcert: &api.ManagedCertificate{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"networking.gke.io/issuer-kind": issuer.ACMEIssuerKind}},
+				Spec:       api.ManagedCertificateSpec{IssuerRef: &v1beta2.IssuerReference{}},
+			},
+			want: issuer.GCEIssuerKind,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := issuerKind(tc.mcert); got != tc.want {
+				t.Errorf("issuerKind() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestJitteredRequeueInterval(t *testing.T) {
+	const jitter = 5 * time.Minute
+
+	testCases := []struct {
+		desc                string
+		untilRotation       time.Duration
+		activeRequeuePeriod time.Duration
+		minWant, maxWant    time.Duration
+	}{
+		{
+			desc:                "far from rotation is capped at activeRequeuePeriod plus jitter",
+			untilRotation:       24 * time.Hour,
+			activeRequeuePeriod: time.Hour,
+			minWant:             time.Hour,
+			maxWant:             time.Hour + jitter,
+		},
+		{
+			desc:                "close to rotation is capped at untilRotation plus jitter instead",
+			untilRotation:       10 * time.Minute,
+			activeRequeuePeriod: time.Hour,
+			minWant:             10 * time.Minute,
+			maxWant:             10*time.Minute + jitter,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				got := jitteredRequeueInterval(tc.untilRotation, tc.activeRequeuePeriod, jitter)
+				if got < tc.minWant || got > tc.maxWant {
+					t.Fatalf("jitteredRequeueInterval() = %v, want between %v and %v", got, tc.minWant, tc.maxWant)
+				}
+			}
+		})
+	}
+}
@@ -18,29 +18,54 @@ limitations under the License.
 package sslcertificatemanager
 
 import (
+	"time"
+
 	"github.com/golang/glog"
 	compute "google.golang.org/api/compute/v0.beta"
 
 	api "github.com/GoogleCloudPlatform/gke-managed-certs/pkg/apis/gke.googleapis.com/v1alpha1"
 	"github.com/GoogleCloudPlatform/gke-managed-certs/pkg/client"
+	"github.com/GoogleCloudPlatform/gke-managed-certs/pkg/metrics"
 	"github.com/GoogleCloudPlatform/gke-managed-certs/pkg/utils/http"
 )
 
 type SslCertificateManager struct {
 	client *client.Clients
+
+	// eventVerbosity gates the informational Create/Delete events below; Warning events (BackendError,
+	// TooManyCertificates, ExpiringSoon) are always recorded regardless of its value.
+	eventVerbosity int32
+
+	// expiryWarningThresholds are the days-until-expiration boundaries, ordered from most to least
+	// urgent, at which ReportExpiry generates an ExpiringSoon Warning event on the ManagedCertificate.
+	expiryWarningThresholds []int32
 }
 
-func New(client *client.Clients) SslCertificateManager {
+func New(client *client.Clients, eventVerbosity int32, expiryWarningThresholds []int32) SslCertificateManager {
 	return SslCertificateManager{
-		client: client,
+		client:                  client,
+		eventVerbosity:          eventVerbosity,
+		expiryWarningThresholds: expiryWarningThresholds,
+	}
+}
+
+// recordInfoEvent runs record if eventVerbosity allows informational events to be generated.
+func (s SslCertificateManager) recordInfoEvent(record func()) {
+	if s.eventVerbosity > 0 {
+		record()
 	}
 }
 
 // Create creates an SslCertificate object. It generates a TooManyCertificates event if SslCertificate quota
-// is exceeded or BackendError event if another generic error occurs. On success it generates a Create event.
+// is exceeded or BackendError event if another generic error occurs. On success it generates a Create event,
+// subject to eventVerbosity.
 func (s SslCertificateManager) Create(sslCertificateName string, mcrt api.ManagedCertificate) error {
 	glog.Infof("Creating SslCertificate %s for ManagedCertificate %s:%s", sslCertificateName, mcrt.Namespace, mcrt.Name)
-	if err := s.client.Ssl.Create(sslCertificateName, mcrt.Spec.Domains); err != nil {
+	err := http.Retry(http.DefaultRetryConfig, func() error {
+		return s.client.Ssl.Create(sslCertificateName, mcrt.Spec.Domains)
+	})
+	metrics.ReportGCECall("create", err)
+	if err != nil {
 		if http.IsQuotaExceeded(err) {
 			s.client.Event.TooManyCertificates(mcrt, err)
 			return err
@@ -49,7 +74,7 @@ func (s SslCertificateManager) Create(sslCertificateName string, mcrt api.Manage
 		s.client.Event.BackendError(mcrt, err)
 		return err
 	}
-	s.client.Event.Create(mcrt, sslCertificateName)
+	s.recordInfoEvent(func() { s.client.Event.Create(mcrt, sslCertificateName) })
 	glog.Infof("Created SslCertificate %s for ManagedCertificate %s:%s", sslCertificateName, mcrt.Namespace, mcrt.Name)
 	return nil
 }
@@ -58,7 +83,11 @@ func (s SslCertificateManager) Create(sslCertificateName string, mcrt api.Manage
 // event. If the SslCertificate object exists and is successfully deleted, a Delete event is generated.
 func (s SslCertificateManager) Delete(sslCertificateName string, mcrt *api.ManagedCertificate) error {
 	glog.Infof("Deleting SslCertificate %s", sslCertificateName)
-	if err := http.IgnoreNotFound(s.client.Ssl.Delete(sslCertificateName)); err != nil {
+	err := http.Retry(http.DefaultRetryConfig, func() error {
+		return s.client.Ssl.Delete(sslCertificateName)
+	})
+	metrics.ReportGCECall("delete", err)
+	if err := http.IgnoreNotFound(err); err != nil {
 		if mcrt != nil {
 			s.client.Event.BackendError(*mcrt, err)
 		}
@@ -67,7 +96,7 @@ func (s SslCertificateManager) Delete(sslCertificateName string, mcrt *api.Manag
 	}
 
 	if mcrt != nil {
-		s.client.Event.Delete(*mcrt, sslCertificateName)
+		s.recordInfoEvent(func() { s.client.Event.Delete(*mcrt, sslCertificateName) })
 	}
 	glog.Infof("Deleted SslCertificate %s", sslCertificateName)
 	return nil
@@ -76,7 +105,13 @@ func (s SslCertificateManager) Delete(sslCertificateName string, mcrt *api.Manag
 // Exists returns true if an SslCertificate exists, false if it is deleted. Error is not nil if an error has occurred
 // and in such case a BackendError event is generated.
 func (s SslCertificateManager) Exists(sslCertificateName string, mcrt *api.ManagedCertificate) (bool, error) {
-	exists, err := s.client.Ssl.Exists(sslCertificateName)
+	var exists bool
+	err := http.Retry(http.DefaultRetryConfig, func() error {
+		var existsErr error
+		exists, existsErr = s.client.Ssl.Exists(sslCertificateName)
+		return existsErr
+	})
+	metrics.ReportGCECall("exists", err)
 	if err != nil {
 		if mcrt != nil {
 			s.client.Event.BackendError(*mcrt, err)
@@ -89,7 +124,13 @@ func (s SslCertificateManager) Exists(sslCertificateName string, mcrt *api.Manag
 
 // Get fetches an SslCertificate object. On error a BackendError event is generated.
 func (s SslCertificateManager) Get(sslCertificateName string, mcrt *api.ManagedCertificate) (*compute.SslCertificate, error) {
-	sslCert, err := s.client.Ssl.Get(sslCertificateName)
+	var sslCert *compute.SslCertificate
+	err := http.Retry(http.DefaultRetryConfig, func() error {
+		var getErr error
+		sslCert, getErr = s.client.Ssl.Get(sslCertificateName)
+		return getErr
+	})
+	metrics.ReportGCECall("get", err)
 	if err != nil {
 		if mcrt != nil {
 			s.client.Event.BackendError(*mcrt, err)
@@ -98,4 +139,38 @@ func (s SslCertificateManager) Get(sslCertificateName string, mcrt *api.ManagedC
 	}
 
 	return sslCert, err
+}
+
+// ReportExpiry exports expireTime, an RFC3339 timestamp, as Prometheus metrics, one data point per
+// domain. If the certificate is within one of expiryWarningThresholds days of expiring, an
+// ExpiringSoon Warning event is generated on the ManagedCertificate so that users relying on
+// `kubectl describe` notice even without Prometheus set up. expireTime is passed in as a string
+// rather than a *compute.SslCertificate, and may already have been fetched through a non-GCE
+// Issuer, so that a caller reconciling both expiry and rotation for the same certificate in one
+// pass - reconcileExpiryAndRotation - pays for only one lookup per reconcile instead of one each,
+// regardless of which Issuer backs the certificate.
+func (s SslCertificateManager) ReportExpiry(sslCertificateName string, mcrt api.ManagedCertificate, expireTimeString string) error {
+	if expireTimeString == "" {
+		// Certificate has not been provisioned yet, nothing to report.
+		return nil
+	}
+
+	expireTime, err := time.Parse(time.RFC3339, expireTimeString)
+	if err != nil {
+		return err
+	}
+
+	for _, domain := range mcrt.Spec.Domains {
+		metrics.ReportExpiry(mcrt.Name, mcrt.Namespace, domain, expireTime)
+	}
+
+	daysLeft := metrics.DaysUntil(expireTime)
+	for _, threshold := range s.expiryWarningThresholds {
+		if daysLeft <= float64(threshold) {
+			s.client.Event.ExpiringSoon(mcrt, sslCertificateName, daysLeft)
+			break
+		}
+	}
+
+	return nil
 }
\ No newline at end of file
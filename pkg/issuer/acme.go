@@ -0,0 +1,436 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package issuer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"golang.org/x/crypto/acme"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	api "managed-certs-gke/pkg/apis/cloud.google.com/v1alpha1"
+)
+
+// acmeHTTP01Port is the port the ACME CA connects to over plain HTTP to validate an http-01
+// challenge, fixed by the ACME spec.
+const acmeHTTP01Port = ":80"
+
+// acmeSecretNamePrefix is the prefix every TLS Secret an issued ACME certificate is stored under is
+// given, mirroring GCECertificateNamePrefix's role for GCE SslCertificates.
+const acmeSecretNamePrefix = "acme-"
+
+// acmeIssuer backs a ManagedCertificate with a certificate obtained from an external ACME issuer
+// (e.g. Let's Encrypt), for clusters without GCE SslCertificate quota or that need certificate
+// properties GCE doesn't offer, such as wildcards. Unlike the GCE issuer, the name callers see and
+// store (in c.state, mcert.Status.CertificateName, and an Ingress's TLS reference) is not something
+// the ACME CA understands - it is the name of the Kubernetes TLS Secret the issued certificate and
+// key are stored in once the order is valid. orders maps that name to the ACME order URL, which is
+// the handle this issuer actually needs to poll or advance the order.
+type acmeIssuer struct {
+	client     *acme.Client
+	responder  *http01Responder
+	kubeClient kubernetes.Interface
+
+	ordersMu sync.Mutex
+	orders   map[string]string
+
+	// orderKeys holds the certificate private key generated for each in-flight order, keyed by
+	// order URL, so that the key used in the CSR at finalize time - and later to build the Secret -
+	// is still available across repeated EnsureCertificate calls.
+	orderKeysMu sync.Mutex
+	orderKeys   map[string]*ecdsa.PrivateKey
+}
+
+// NewACMEIssuer returns an Issuer that registers with directoryURL and provisions certificates
+// through ACME HTTP-01 challenges, storing each issued certificate as a TLS Secret via kubeClient.
+// accountKey is the account's private key; a new account is registered with the directory on first
+// use if one doesn't already exist for it. It also starts an HTTP server on acmeHTTP01Port to answer
+// the CA's http-01 challenge requests.
+func NewACMEIssuer(directoryURL string, accountKey *ecdsa.PrivateKey, kubeClient kubernetes.Interface) (Issuer, error) {
+	if accountKey == nil {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("could not generate ACME account key: %v", err)
+		}
+		accountKey = key
+	}
+
+	client := &acme.Client{DirectoryURL: directoryURL, Key: accountKey}
+
+	if _, err := client.Register(context.Background(), &acme.Account{}, acme.AcceptTOS); err != nil && err != acme.ErrAccountAlreadyExists {
+		return nil, fmt.Errorf("could not register ACME account with %s: %v", directoryURL, err)
+	}
+
+	responder := newHTTP01Responder()
+	responder.start()
+
+	return &acmeIssuer{
+		client:     client,
+		responder:  responder,
+		kubeClient: kubeClient,
+		orders:     make(map[string]string),
+		orderKeys:  make(map[string]*ecdsa.PrivateKey),
+	}, nil
+}
+
+// trackOrder records that secretName, the name EnsureCertificate returns to the caller, refers to
+// the ACME order orderURI.
+func (a *acmeIssuer) trackOrder(secretName, orderURI string) {
+	a.ordersMu.Lock()
+	defer a.ordersMu.Unlock()
+	a.orders[secretName] = orderURI
+}
+
+// orderURI returns the ACME order URL secretName was tracked against.
+func (a *acmeIssuer) orderURI(secretName string) (string, bool) {
+	a.ordersMu.Lock()
+	defer a.ordersMu.Unlock()
+	orderURI, ok := a.orders[secretName]
+	return orderURI, ok
+}
+
+// startOrder creates a fresh ACME order for mcrt's domains and returns its order URI, factored out
+// of EnsureCertificate so both a brand new certificate and recovery from a lost in-memory order can
+// share the same order-creation call.
+func (a *acmeIssuer) startOrder(ctx context.Context, mcrt api.ManagedCertificate) (string, error) {
+	order, err := a.client.AuthorizeOrder(ctx, acme.DomainIDs(mcrt.Spec.Domains...))
+	if err != nil {
+		return "", err
+	}
+
+	return order.URI, nil
+}
+
+// EnsureCertificate drives one step of the ACME order tracked under name - creating one if name is
+// empty, otherwise authorizing its pending challenges, finalizing it once ready, or fetching and
+// storing the issued certificate once valid - and reports whether that certificate has been stored
+// and is ready to be referenced by an Ingress. It is safe, and expected, to be called repeatedly as
+// the order progresses through its states; each call only does the work appropriate to the order's
+// current status, mirroring how rotateIfNeeded drives the GCE issuer one reconcile at a time.
+func (a *acmeIssuer) EnsureCertificate(name string, mcrt api.ManagedCertificate) (string, bool, error) {
+	ctx := context.Background()
+
+	if name == "" {
+		orderURI, err := a.startOrder(ctx, mcrt)
+		if err != nil {
+			return "", false, err
+		}
+
+		secretName := fmt.Sprintf("%s%s-%d", acmeSecretNamePrefix, mcrt.ObjectMeta.Name, time.Now().UnixNano())
+		a.trackOrder(secretName, orderURI)
+
+		glog.Infof("Created ACME order %s for ManagedCertificate %s:%s, tracked as Secret %s", orderURI, mcrt.Namespace, mcrt.Name, secretName)
+		return secretName, false, nil
+	}
+
+	orderURI, ok := a.orderURI(name)
+	if !ok {
+		// orders is an in-memory map with no persistence, so it is wiped by any process restart -
+		// including the one OnStoppedLeading forces on every leader failover. Rather than getting
+		// stuck on a lookup that can never succeed again until someone manually intervenes, start a
+		// replacement order under the same secret name so EnsureCertificate keeps making progress on
+		// its own.
+		glog.Warningf("No ACME order tracked for %s, likely lost across a restart; starting a replacement order", name)
+
+		newOrderURI, err := a.startOrder(ctx, mcrt)
+		if err != nil {
+			return name, false, err
+		}
+
+		a.trackOrder(name, newOrderURI)
+		glog.Infof("Started replacement ACME order %s for Secret %s", newOrderURI, name)
+		return name, false, nil
+	}
+
+	order, err := a.client.GetOrder(ctx, orderURI)
+	if err != nil {
+		return name, false, err
+	}
+
+	switch order.Status {
+	case acme.StatusValid:
+		if err := a.storeCertificate(ctx, name, order, mcrt); err != nil {
+			return name, false, err
+		}
+		return name, true, nil
+	case acme.StatusPending:
+		return name, false, a.authorizeChallenges(ctx, order)
+	case acme.StatusReady:
+		return name, false, a.finalizeOrder(ctx, order, mcrt)
+	case acme.StatusProcessing:
+		// The CA is validating challenges or issuing the certificate; nothing to do until the
+		// next reconcile.
+		return name, false, nil
+	default:
+		return name, false, fmt.Errorf("ACME order %s is in unexpected state %q", orderURI, order.Status)
+	}
+}
+
+// authorizeChallenges publishes the key authorization for, and accepts, the http-01 challenge of
+// every one of order's authorizations that isn't valid yet. It does not wait for the CA to finish
+// validating - that is observed on a later reconcile via order.Status.
+func (a *acmeIssuer) authorizeChallenges(ctx context.Context, order *acme.Order) error {
+	for _, authzURL := range order.AuthzURLs {
+		authz, err := a.client.GetAuthorization(ctx, authzURL)
+		if err != nil {
+			return err
+		}
+		if authz.Status == acme.StatusValid {
+			continue
+		}
+
+		var challenge *acme.Challenge
+		for _, c := range authz.Challenges {
+			if c.Type == "http-01" {
+				challenge = c
+				break
+			}
+		}
+		if challenge == nil {
+			return fmt.Errorf("ACME authorization %s for %s has no http-01 challenge", authzURL, authz.Identifier.Value)
+		}
+		if challenge.Status == acme.StatusValid || challenge.Status == acme.StatusProcessing {
+			continue
+		}
+
+		keyAuth, err := a.client.HTTP01ChallengeResponse(challenge.Token)
+		if err != nil {
+			return err
+		}
+		a.responder.publish(a.client.HTTP01ChallengePath(challenge.Token), keyAuth)
+
+		if _, err := a.client.Accept(ctx, challenge); err != nil {
+			return err
+		}
+
+		glog.Infof("Accepted ACME http-01 challenge for %s", authz.Identifier.Value)
+	}
+
+	return nil
+}
+
+// finalizeOrder submits a CSR for order's domains, moving it from StatusReady to StatusProcessing.
+// The certificate private key is generated once per order and cached in orderKeys, since it must
+// match the CSR submitted here for as long as the order is in flight.
+func (a *acmeIssuer) finalizeOrder(ctx context.Context, order *acme.Order, mcrt api.ManagedCertificate) error {
+	key, err := a.certKey(order.URI)
+	if err != nil {
+		return err
+	}
+
+	var commonName string
+	if len(mcrt.Spec.Domains) > 0 {
+		commonName = mcrt.Spec.Domains[0]
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: commonName},
+		DNSNames: mcrt.Spec.Domains,
+	}, key)
+	if err != nil {
+		return fmt.Errorf("could not create CSR for ACME order %s: %v", order.URI, err)
+	}
+
+	if _, _, err := a.client.CreateOrderCert(ctx, order.FinalizeURL, csr, true); err != nil {
+		return err
+	}
+
+	glog.Infof("Finalized ACME order %s for ManagedCertificate %s:%s", order.URI, mcrt.Namespace, mcrt.Name)
+	return nil
+}
+
+// certKey returns the cached certificate private key for orderURI, generating and caching one the
+// first time it is requested.
+func (a *acmeIssuer) certKey(orderURI string) (*ecdsa.PrivateKey, error) {
+	a.orderKeysMu.Lock()
+	defer a.orderKeysMu.Unlock()
+
+	if key, ok := a.orderKeys[orderURI]; ok {
+		return key, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate certificate key for ACME order %s: %v", orderURI, err)
+	}
+
+	a.orderKeys[orderURI] = key
+	return key, nil
+}
+
+// storeCertificate fetches order's issued certificate chain and stores it, PEM-encoded alongside
+// the private key finalizeOrder generated for its CSR, as a kubernetes.io/tls Secret named
+// secretName in mcrt's namespace. That Secret is the only place outside this issuer the certificate
+// material needs to live, since it's what replaceIngressTLSSecretReference points Ingresses at.
+func (a *acmeIssuer) storeCertificate(ctx context.Context, secretName string, order *acme.Order, mcrt api.ManagedCertificate) error {
+	key, err := a.certKey(order.URI)
+	if err != nil {
+		return err
+	}
+
+	der, err := a.client.FetchCert(ctx, order.CertURL, true)
+	if err != nil {
+		return fmt.Errorf("could not fetch certificate for ACME order %s: %v", order.URI, err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("could not marshal certificate key for ACME order %s: %v", order.URI, err)
+	}
+
+	var certPEM []byte
+	for _, block := range der {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: block})...)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: mcrt.Namespace},
+		Type:       corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       certPEM,
+			corev1.TLSPrivateKeyKey: keyPEM,
+		},
+	}
+
+	if _, err := a.kubeClient.CoreV1().Secrets(mcrt.Namespace).Create(secret); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("could not create TLS secret %s:%s for ACME order %s: %v", mcrt.Namespace, secretName, order.URI, err)
+		}
+		if _, err := a.kubeClient.CoreV1().Secrets(mcrt.Namespace).Update(secret); err != nil {
+			return fmt.Errorf("could not update TLS secret %s:%s for ACME order %s: %v", mcrt.Namespace, secretName, order.URI, err)
+		}
+	}
+
+	glog.Infof("Stored certificate for ACME order %s as Secret %s:%s", order.URI, mcrt.Namespace, secretName)
+	return nil
+}
+
+// ExpireTime implements Issuer.ExpireTime. name is the TLS Secret storeCertificate wrote the
+// issued certificate into, not anything the ACME client itself tracks, so unlike EnsureCertificate
+// this reads straight from the Secret rather than a.orders - it needs to keep working even for a
+// Secret left over from an order this process no longer has in memory, e.g. after a leader failover.
+func (a *acmeIssuer) ExpireTime(name string, mcrt *api.ManagedCertificate) (string, error) {
+	if mcrt == nil {
+		return "", fmt.Errorf("ACME issuer cannot look up Secret %s without a ManagedCertificate namespace", name)
+	}
+
+	secret, err := a.kubeClient.CoreV1().Secrets(mcrt.Namespace).Get(name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		// Not issued yet.
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	block, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	if block == nil {
+		return "", fmt.Errorf("TLS secret %s:%s has no PEM-encoded certificate", mcrt.Namespace, name)
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("could not parse certificate in TLS secret %s:%s: %v", mcrt.Namespace, name, err)
+	}
+
+	return cert.NotAfter.Format(time.RFC3339), nil
+}
+
+func (a *acmeIssuer) DeleteCertificate(name string, mcrt *api.ManagedCertificate) error {
+	orderURI, tracked := a.orderURI(name)
+
+	if tracked {
+		a.orderKeysMu.Lock()
+		delete(a.orderKeys, orderURI)
+		a.orderKeysMu.Unlock()
+	}
+
+	a.ordersMu.Lock()
+	delete(a.orders, name)
+	a.ordersMu.Unlock()
+
+	// ACME orders expire on their own; there is no explicit delete API, mirroring how an expired
+	// Let's Encrypt order simply becomes unusable without intervention. The TLS Secret it was
+	// stored into, on the other hand, does need explicit cleanup.
+	if mcrt == nil {
+		return nil
+	}
+
+	err := a.kubeClient.CoreV1().Secrets(mcrt.Namespace).Delete(name, &metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// http01Responder serves ACME http-01 challenge responses on acmeHTTP01Port. The CA fetches
+// http://<domain>/.well-known/acme-challenge/<token> over plain HTTP before any Ingress or
+// certificate exists for the domain, so this has to be a bare HTTP server rather than routed
+// through the cluster's own Ingress.
+type http01Responder struct {
+	mu        sync.RWMutex
+	responses map[string]string
+}
+
+func newHTTP01Responder() *http01Responder {
+	return &http01Responder{responses: make(map[string]string)}
+}
+
+func (h *http01Responder) publish(path, keyAuth string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.responses[path] = keyAuth
+}
+
+func (h *http01Responder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	keyAuth, ok := h.responses[r.URL.Path]
+	h.mu.RUnlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Write([]byte(keyAuth))
+}
+
+// start begins serving challenge responses in the background. A failure to bind the port is
+// logged rather than returned, since it only breaks ACME issuance and shouldn't take down the
+// whole controller.
+func (h *http01Responder) start() {
+	go func() {
+		if err := http.ListenAndServe(acmeHTTP01Port, h); err != nil {
+			glog.Errorf("ACME http-01 challenge responder stopped: %v", err)
+		}
+	}()
+}
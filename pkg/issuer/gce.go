@@ -0,0 +1,89 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package issuer
+
+import (
+	"fmt"
+	"time"
+
+	gceapi "github.com/GoogleCloudPlatform/gke-managed-certs/pkg/apis/gke.googleapis.com/v1alpha1"
+	"github.com/GoogleCloudPlatform/gke-managed-certs/pkg/controller/sslcertificatemanager"
+
+	api "managed-certs-gke/pkg/apis/cloud.google.com/v1alpha1"
+)
+
+// GCECertificateNamePrefix is the prefix every SslCertificate the GCE issuer creates is given, so
+// the controller's coarse sweep only ever considers deleting certificates it plausibly owns.
+const GCECertificateNamePrefix = "mcrt-"
+
+// gceIssuer is the default Issuer, backing a ManagedCertificate with a Google-managed GCE
+// SslCertificate. It is a thin adapter over the pre-existing SslCertificateManager so that
+// behavior for ManagedCertificates without IssuerRef set is unchanged.
+type gceIssuer struct {
+	manager sslcertificatemanager.SslCertificateManager
+}
+
+// NewGCEIssuer returns the default Issuer, which provisions Google-managed SslCertificates.
+func NewGCEIssuer(manager sslcertificatemanager.SslCertificateManager) Issuer {
+	return gceIssuer{manager: manager}
+}
+
+// EnsureCertificate implements Issuer.EnsureCertificate, generating a new
+// GCECertificateNamePrefix-prefixed name, suffixed with the current time in nanoseconds, when name
+// is empty, matching the "creating one if name is empty" contract every other Issuer implementation
+// honors.
+func (g gceIssuer) EnsureCertificate(name string, mcrt api.ManagedCertificate) (string, bool, error) {
+	if name == "" {
+		name = fmt.Sprintf("%s%s-%d", GCECertificateNamePrefix, mcrt.ObjectMeta.Name, time.Now().UnixNano())
+	}
+
+	gceMcrt := gceapi.ManagedCertificate{Spec: gceapi.ManagedCertificateSpec{Domains: mcrt.Spec.Domains}}
+	gceMcrt.ObjectMeta = mcrt.ObjectMeta
+
+	exists, err := g.manager.Exists(name, nil)
+	if err != nil {
+		return name, false, err
+	}
+	if !exists {
+		if err := g.manager.Create(name, gceMcrt); err != nil {
+			return name, false, err
+		}
+	}
+
+	sslCert, err := g.manager.Get(name, nil)
+	if err != nil {
+		return name, false, err
+	}
+
+	return name, sslCert.Status == "ACTIVE", nil
+}
+
+// ExpireTime implements Issuer.ExpireTime by reading the backing SslCertificate's ExpireTime
+// field directly; GCE already reports it as an RFC3339 timestamp, or "" before the certificate has
+// been provisioned, so there is nothing to translate.
+func (g gceIssuer) ExpireTime(name string, mcrt *api.ManagedCertificate) (string, error) {
+	sslCert, err := g.manager.Get(name, mcrt)
+	if err != nil {
+		return "", err
+	}
+
+	return sslCert.ExpireTime, nil
+}
+
+func (g gceIssuer) DeleteCertificate(name string, mcrt *api.ManagedCertificate) error {
+	return g.manager.Delete(name, nil)
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package issuer abstracts over the backend that actually provisions TLS certificates for a
+// ManagedCertificate. The default backend is a GCE SslCertificate, but clusters without
+// SslCertificate quota, or that need wildcard, EV or short-lived certificates, can instead
+// reference an external ACME issuer via ManagedCertificateSpec.IssuerRef while keeping the same
+// ManagedCertificate UX.
+package issuer
+
+import (
+	api "managed-certs-gke/pkg/apis/cloud.google.com/v1alpha1"
+)
+
+// Issuer provisions and tears down the certificate object backing a ManagedCertificate. Name is an
+// opaque, issuer-specific identifier - for the GCE issuer it is an SslCertificate name, for the ACME
+// issuer it is the name of the TLS Secret the issued certificate is stored in.
+type Issuer interface {
+	// EnsureCertificate makes sure a certificate object for mcrt exists, creating one if name is
+	// empty. It returns the (possibly newly created) name and whether the certificate has reached
+	// an Active state ready to be referenced by an Ingress.
+	EnsureCertificate(name string, mcrt api.ManagedCertificate) (newName string, active bool, err error)
+
+	// ExpireTime returns the RFC3339 timestamp at which the certificate object identified by name
+	// expires, or "" if it hasn't finished provisioning yet. Callers deciding whether a certificate
+	// is due for rotation, or reporting its expiry metrics, go through this instead of assuming the
+	// certificate is a GCE SslCertificate, so that both work the same way for every Issuer.
+	ExpireTime(name string, mcrt *api.ManagedCertificate) (string, error)
+
+	// DeleteCertificate deletes the certificate object identified by name, if it exists.
+	DeleteCertificate(name string, mcrt *api.ManagedCertificate) error
+}
+
+// GCEIssuerKind is the Kind used to select the default GCE SslCertificate Issuer; it is also the
+// zero value, so ManagedCertificates that don't set IssuerRef keep today's behavior.
+const GCEIssuerKind = ""
+
+// ACMEIssuerKind selects the ACME-backed Issuer. Exported so callers outside this package (e.g. the
+// Ingress cutover logic, which has to rewrite a GCE-specific annotation for one issuer and an
+// Ingress TLS Secret reference for the other) can tell the two apart.
+const ACMEIssuerKind = "ACMEIssuer"
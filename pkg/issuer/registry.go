@@ -0,0 +1,50 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package issuer
+
+import (
+	"fmt"
+)
+
+// Registry resolves the Issuer to use for a ManagedCertificate based on its IssuerRef, falling
+// back to the default GCE issuer when IssuerRef is unset.
+type Registry struct {
+	defaultIssuer Issuer
+	acmeIssuer    Issuer
+}
+
+// NewRegistry builds a Registry backed by defaultIssuer for ManagedCertificates without IssuerRef
+// set, and acmeIssuer (which may be nil if no ACME directory was configured) for those with
+// IssuerRef.Kind == "ACMEIssuer".
+func NewRegistry(defaultIssuer, acmeIssuer Issuer) Registry {
+	return Registry{defaultIssuer: defaultIssuer, acmeIssuer: acmeIssuer}
+}
+
+// Select returns the Issuer registered for issuerKind.
+func (r Registry) Select(issuerKind string) (Issuer, error) {
+	switch issuerKind {
+	case GCEIssuerKind:
+		return r.defaultIssuer, nil
+	case ACMEIssuerKind:
+		if r.acmeIssuer == nil {
+			return nil, fmt.Errorf("ManagedCertificate references an %s issuer, but none is configured", ACMEIssuerKind)
+		}
+		return r.acmeIssuer, nil
+	default:
+		return nil, fmt.Errorf("unsupported issuerRef.kind %q", issuerKind)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package issuer
+
+import (
+	"testing"
+
+	api "managed-certs-gke/pkg/apis/cloud.google.com/v1alpha1"
+)
+
+// fakeIssuer is a minimal Issuer stand-in, distinguishable by pointer identity, so tests can check
+// Select returned the expected instance without needing a working implementation.
+type fakeIssuer struct{}
+
+func (fakeIssuer) EnsureCertificate(name string, mcrt api.ManagedCertificate) (string, bool, error) {
+	return "", false, nil
+}
+func (fakeIssuer) ExpireTime(name string, mcrt *api.ManagedCertificate) (string, error) { return "", nil }
+func (fakeIssuer) DeleteCertificate(name string, mcrt *api.ManagedCertificate) error     { return nil }
+
+func TestRegistrySelect(t *testing.T) {
+	defaultIssuer := &fakeIssuer{}
+	acmeIssuer := &fakeIssuer{}
+
+	testCases := []struct {
+		desc       string
+		registry   Registry
+		issuerKind string
+		want       Issuer
+		wantErr    bool
+	}{
+		{
+			desc:       "GCEIssuerKind selects the default issuer",
+			registry:   NewRegistry(defaultIssuer, acmeIssuer),
+			issuerKind: GCEIssuerKind,
+			want:       defaultIssuer,
+		},
+		{
+			desc:       "ACMEIssuerKind selects the ACME issuer",
+			registry:   NewRegistry(defaultIssuer, acmeIssuer),
+			issuerKind: ACMEIssuerKind,
+			want:       acmeIssuer,
+		},
+		{
+			desc:       "ACMEIssuerKind with no ACME issuer configured errors",
+			registry:   NewRegistry(defaultIssuer, nil),
+			issuerKind: ACMEIssuerKind,
+			wantErr:    true,
+		},
+		{
+			desc:       "unsupported kind errors",
+			registry:   NewRegistry(defaultIssuer, acmeIssuer),
+			issuerKind: "SomeOtherIssuer",
+			wantErr:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := tc.registry.Select(tc.issuerKind)
+
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("Select(%q) = nil error, want an error", tc.issuerKind)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Select(%q) = %v, want no error", tc.issuerKind, err)
+			}
+			if got != tc.want {
+				t.Errorf("Select(%q) = %v, want %v", tc.issuerKind, got, tc.want)
+			}
+		})
+	}
+}
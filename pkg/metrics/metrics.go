@@ -0,0 +1,120 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics exports Prometheus metrics describing the state of SslCertificate objects
+// managed by the controller, such as time remaining until expiration.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/api/googleapi"
+)
+
+const subsystem = "managedcertificate"
+
+var (
+	expirationSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: subsystem,
+		Name:      "expiration_seconds",
+		Help:      "Unix timestamp, in seconds, at which the SslCertificate backing a ManagedCertificate domain expires",
+	}, []string{"name", "namespace", "domain"})
+
+	daysUntilExpiration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: subsystem,
+		Name:      "days_until_expiration",
+		Help:      "Number of days remaining until the SslCertificate backing a ManagedCertificate domain expires",
+	}, []string{"name", "namespace", "domain"})
+
+	queueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: subsystem,
+		Name:      "workqueue_depth",
+		Help:      "Number of items currently waiting in a controller workqueue",
+	}, []string{"queue"})
+
+	gceCallsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: subsystem,
+		Name:      "gce_calls_total",
+		Help:      "Number of GCE API calls made by the controller, by operation and outcome",
+	}, []string{"operation", "code"})
+
+	reconcileLatencySeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Subsystem: subsystem,
+		Name:      "reconcile_latency_seconds",
+		Help:      "Time taken to reconcile a single ManagedCertificate, by outcome",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"result"})
+)
+
+func init() {
+	prometheus.MustRegister(expirationSeconds, daysUntilExpiration, queueDepth, gceCallsTotal, reconcileLatencySeconds)
+}
+
+// ReportExpiry sets the expiration_seconds and days_until_expiration gauges for the given
+// ManagedCertificate domain to reflect expireTime.
+func ReportExpiry(name, namespace, domain string, expireTime time.Time) {
+	expirationSeconds.WithLabelValues(name, namespace, domain).Set(float64(expireTime.Unix()))
+	daysUntilExpiration.WithLabelValues(name, namespace, domain).Set(DaysUntil(expireTime))
+}
+
+// DeleteExpiry removes previously reported expiry metrics for a ManagedCertificate domain, e.g. once it
+// no longer exists in the cluster.
+func DeleteExpiry(name, namespace, domain string) {
+	expirationSeconds.DeleteLabelValues(name, namespace, domain)
+	daysUntilExpiration.DeleteLabelValues(name, namespace, domain)
+}
+
+// DaysUntil returns the number of days, as a fraction, between now and expireTime. The result is
+// negative if expireTime is in the past.
+func DaysUntil(expireTime time.Time) float64 {
+	return time.Until(expireTime).Hours() / 24
+}
+
+// ReportQueueDepth sets the workqueue_depth gauge for a named controller workqueue, e.g. "mcert"
+// or "mcert_expiry", so operators can tell whether the controller is keeping up.
+func ReportQueueDepth(queue string, depth int) {
+	queueDepth.WithLabelValues(queue).Set(float64(depth))
+}
+
+// ReportGCECall increments the GCE API call counter for operation (e.g. "create", "get", "delete",
+// "exists"). err is classified into a code label: "ok", "5xx", "429" or "error".
+func ReportGCECall(operation string, err error) {
+	gceCallsTotal.WithLabelValues(operation, classifyGCEError(err)).Inc()
+}
+
+// ObserveReconcileLatency records how long a single ManagedCertificate reconcile took. result
+// should be "success" or "error".
+func ObserveReconcileLatency(result string, duration time.Duration) {
+	reconcileLatencySeconds.WithLabelValues(result).Observe(duration.Seconds())
+}
+
+func classifyGCEError(err error) string {
+	if err == nil {
+		return "ok"
+	}
+
+	if apiErr, ok := err.(*googleapi.Error); ok {
+		switch {
+		case apiErr.Code == 429:
+			return "429"
+		case apiErr.Code >= 500:
+			return "5xx"
+		}
+	}
+
+	return "error"
+}
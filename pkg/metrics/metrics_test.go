@@ -0,0 +1,68 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+func TestClassifyGCEError(t *testing.T) {
+	testCases := []struct {
+		desc string
+		err  error
+		want string
+	}{
+		{desc: "nil error", err: nil, want: "ok"},
+		{desc: "429", err: &googleapi.Error{Code: http.StatusTooManyRequests}, want: "429"},
+		{desc: "500", err: &googleapi.Error{Code: http.StatusInternalServerError}, want: "5xx"},
+		{desc: "599", err: &googleapi.Error{Code: 599}, want: "5xx"},
+		{desc: "404 is not classified as an outage", err: &googleapi.Error{Code: http.StatusNotFound}, want: "error"},
+		{desc: "non-googleapi error", err: errors.New("boom"), want: "error"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := classifyGCEError(tc.err); got != tc.want {
+				t.Errorf("classifyGCEError(%v) = %q, want %q", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDaysUntil(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		expireTime time.Time
+		want       float64
+	}{
+		{desc: "30 days from now", expireTime: time.Now().Add(30 * 24 * time.Hour), want: 30},
+		{desc: "in the past", expireTime: time.Now().Add(-24 * time.Hour), want: -1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := DaysUntil(tc.expireTime); got < tc.want-0.01 || got > tc.want+0.01 {
+				t.Errorf("DaysUntil() = %v, want approximately %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/golang/glog"
+	"google.golang.org/api/googleapi"
+)
+
+// RetryConfig bounds the exponential backoff Retry applies to a failing call.
+type RetryConfig struct {
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	MaxAttempts    int
+}
+
+// DefaultRetryConfig is used by callers that don't need a custom backoff schedule.
+var DefaultRetryConfig = RetryConfig{
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     30 * time.Second,
+	MaxAttempts:    5,
+}
+
+// Retry calls f until it succeeds, f's error is not retryable, or cfg.MaxAttempts is reached.
+// A GCE 5xx error is retried with exponential backoff starting at cfg.InitialBackoff; a 429 is
+// retried after the response's Retry-After header, when it is a whole number of seconds, capped at
+// cfg.MaxBackoff, falling back to the same exponential backoff otherwise. Any other error is
+// returned immediately.
+func Retry(cfg RetryConfig, f func() error) error {
+	backoff := cfg.InitialBackoff
+
+	var err error
+	for attempt := 0; attempt < cfg.MaxAttempts; attempt++ {
+		err = f()
+		if err == nil {
+			return nil
+		}
+
+		delay, retryable := retryDelay(err, backoff, cfg.MaxBackoff)
+		if !retryable || attempt+1 >= cfg.MaxAttempts {
+			return err
+		}
+
+		glog.Infof("Retrying GCE call in %v after attempt %d/%d failed: %v", delay, attempt+1, cfg.MaxAttempts, err)
+		time.Sleep(delay)
+
+		backoff *= 2
+		if backoff > cfg.MaxBackoff {
+			backoff = cfg.MaxBackoff
+		}
+	}
+
+	return err
+}
+
+// retryDelay reports how long to wait before retrying err, and whether it is retryable at all.
+// maxBackoff caps not just the exponential-backoff branch but also a 429's Retry-After, since
+// Retry blocks the caller's goroutine for however long it returns - honoring an unbounded
+// Retry-After (GCE has been observed to send values in the thousands of seconds) would stall the
+// single reconcile worker, and every ManagedCertificate queued behind it, for that long.
+func retryDelay(err error, backoff, maxBackoff time.Duration) (time.Duration, bool) {
+	apiErr, ok := err.(*googleapi.Error)
+	if !ok {
+		return 0, false
+	}
+
+	if apiErr.Code == http.StatusTooManyRequests {
+		if retryAfter := apiErr.Header.Get("Retry-After"); retryAfter != "" {
+			if seconds, parseErr := strconv.Atoi(retryAfter); parseErr == nil {
+				delay := time.Duration(seconds) * time.Second
+				if delay > maxBackoff {
+					delay = maxBackoff
+				}
+				return delay, true
+			}
+		}
+		return backoff, true
+	}
+
+	if apiErr.Code >= 500 && apiErr.Code < 600 {
+		return backoff, true
+	}
+
+	return 0, false
+}
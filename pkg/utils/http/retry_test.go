@@ -0,0 +1,99 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+func TestRetryDelay(t *testing.T) {
+	const backoff = 2 * time.Second
+	const maxBackoff = 30 * time.Second
+
+	testCases := []struct {
+		desc          string
+		err           error
+		wantDelay     time.Duration
+		wantRetryable bool
+	}{
+		{
+			desc:          "non-googleapi error is not retryable",
+			err:           errors.New("boom"),
+			wantDelay:     0,
+			wantRetryable: false,
+		},
+		{
+			desc:          "404 is not retryable",
+			err:           &googleapi.Error{Code: http.StatusNotFound},
+			wantDelay:     0,
+			wantRetryable: false,
+		},
+		{
+			desc:          "500 retries with backoff",
+			err:           &googleapi.Error{Code: http.StatusInternalServerError},
+			wantDelay:     backoff,
+			wantRetryable: true,
+		},
+		{
+			desc:          "429 without Retry-After retries with backoff",
+			err:           &googleapi.Error{Code: http.StatusTooManyRequests},
+			wantDelay:     backoff,
+			wantRetryable: true,
+		},
+		{
+			desc: "429 with Retry-After under maxBackoff honors it verbatim",
+			err: &googleapi.Error{
+				Code:   http.StatusTooManyRequests,
+				Header: http.Header{"Retry-After": []string{"5"}},
+			},
+			wantDelay:     5 * time.Second,
+			wantRetryable: true,
+		},
+		{
+			desc: "429 with Retry-After over maxBackoff is capped",
+			err: &googleapi.Error{
+				Code:   http.StatusTooManyRequests,
+				Header: http.Header{"Retry-After": []string{"3600"}},
+			},
+			wantDelay:     maxBackoff,
+			wantRetryable: true,
+		},
+		{
+			desc: "429 with an unparseable Retry-After falls back to backoff",
+			err: &googleapi.Error{
+				Code:   http.StatusTooManyRequests,
+				Header: http.Header{"Retry-After": []string{"not-a-number"}},
+			},
+			wantDelay:     backoff,
+			wantRetryable: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			delay, retryable := retryDelay(tc.err, backoff, maxBackoff)
+			if delay != tc.wantDelay || retryable != tc.wantRetryable {
+				t.Errorf("retryDelay() = (%v, %v), want (%v, %v)", delay, retryable, tc.wantDelay, tc.wantRetryable)
+			}
+		})
+	}
+}